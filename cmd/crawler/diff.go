@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+var changeTypes = []macpack.ChangeType{
+	macpack.ChangeAdded,
+	macpack.ChangeRemoved,
+	macpack.ChangeReassigned,
+	macpack.ChangeRenamed,
+	macpack.ChangeMoved,
+	macpack.ChangeRegistry,
+}
+
+// runDiff compares two snapshots of any supported format and writes the
+// changes grouped by registry to w.
+func runDiff(w io.Writer, oldFile, newFile, format string) error {
+	old, err := macpack.New(macpack.WithLocalSource(oldFile))
+	if err != nil {
+		return fmt.Errorf("%s: %w", oldFile, err)
+	}
+	updated, err := macpack.New(macpack.WithLocalSource(newFile))
+	if err != nil {
+		return fmt.Errorf("%s: %w", newFile, err)
+	}
+	changes := macpack.Diff(old, updated)
+	switch format {
+	case "text":
+		writeDiffText(w, changes)
+		return nil
+	case "json":
+		return writeDiffJSON(w, changes)
+	}
+	return fmt.Errorf("unknown format: %q", format)
+}
+
+// byRegistry groups the changes by registry, the registries are sorted by
+// name.
+func byRegistry(changes []macpack.Change) ([]macpack.Registry, map[macpack.Registry][]macpack.Change) {
+	var (
+		regs   []macpack.Registry
+		groups = make(map[macpack.Registry][]macpack.Change)
+	)
+	for _, c := range changes {
+		if _, ok := groups[c.Registry]; !ok {
+			regs = append(regs, c.Registry)
+		}
+		groups[c.Registry] = append(groups[c.Registry], c)
+	}
+	sort.Slice(regs, func(i, j int) bool { return regs[i] < regs[j] })
+	return regs, groups
+}
+
+func count(changes []macpack.Change) map[macpack.ChangeType]int {
+	n := make(map[macpack.ChangeType]int)
+	for _, c := range changes {
+		n[c.Type]++
+	}
+	return n
+}
+
+func writeDiffText(w io.Writer, changes []macpack.Change) {
+	if len(changes) == 0 {
+		fmt.Fprintln(w, "no changes")
+		return
+	}
+	regs, groups := byRegistry(changes)
+	for _, reg := range regs {
+		n := count(groups[reg])
+		fmt.Fprintf(w, "%s:", reg)
+		for i, t := range changeTypes {
+			sep := ","
+			if i == 0 {
+				sep = ""
+			}
+			fmt.Fprintf(w, "%s %d %s", sep, n[t], t)
+		}
+		fmt.Fprintln(w)
+		for _, c := range groups[reg] {
+			switch c.Type {
+			case macpack.ChangeAdded:
+				fmt.Fprintf(w, "  + %-9s %s\n", c.Prefix, c.New.Name)
+			case macpack.ChangeRemoved:
+				fmt.Fprintf(w, "  - %-9s %s\n", c.Prefix, c.Old.Name)
+			case macpack.ChangeRegistry:
+				fmt.Fprintf(w, "  ~ %-9s %s: %s -> %s\n", c.Prefix, c.New.Name, c.OldRegistry, c.Registry)
+			case macpack.ChangeMoved:
+				fmt.Fprintf(w, "  ~ %-9s %s: %q -> %q\n", c.Prefix, c.New.Name, c.Old.Address, c.New.Address)
+			default:
+				fmt.Fprintf(w, "  ~ %-9s %s -> %s (%s)\n", c.Prefix, c.Old.Name, c.New.Name, c.Type)
+			}
+		}
+	}
+}
+
+type diffOrg struct {
+	Name    string `json:"name"`
+	Address string `json:"address,omitempty"`
+	Country string `json:"country,omitempty"`
+}
+
+type diffChange struct {
+	Type   macpack.ChangeType `json:"type"`
+	Prefix string             `json:"prefix"`
+	// OldRegistry is set for registry changes.
+	OldRegistry macpack.Registry `json:"old_registry,omitempty"`
+	Old         *diffOrg         `json:"old,omitempty"`
+	New         *diffOrg         `json:"new,omitempty"`
+}
+
+type diffRegistry struct {
+	Registry macpack.Registry           `json:"registry"`
+	Summary  map[macpack.ChangeType]int `json:"summary"`
+	Changes  []diffChange               `json:"changes"`
+}
+
+func newDiffOrg(o *macpack.Organization) *diffOrg {
+	if o == nil {
+		return nil
+	}
+	return &diffOrg{Name: o.Name, Address: o.Address, Country: o.Country}
+}
+
+func writeDiffJSON(w io.Writer, changes []macpack.Change) error {
+	regs, groups := byRegistry(changes)
+	out := make([]diffRegistry, 0, len(regs))
+	for _, reg := range regs {
+		dr := diffRegistry{Registry: reg, Summary: count(groups[reg])}
+		for _, c := range groups[reg] {
+			dr.Changes = append(dr.Changes, diffChange{
+				Type:        c.Type,
+				Prefix:      c.Prefix.String(),
+				OldRegistry: c.OldRegistry,
+				Old:         newDiffOrg(c.Old),
+				New:         newDiffOrg(c.New),
+			})
+		}
+		out = append(out, dr)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
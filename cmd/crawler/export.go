@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/frzifus/vlookup/pkg/crawler"
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+// export merges the tables of urls into a single normalized csv. The tables
+// are looked up in the manifest m, which also lists tables of earlier runs,
+// those are not exported. Tables later in urls take precedence for the same
+// prefix.
+func export(dir string, m *crawler.Manifest, urls []string, file string) error {
+	names := tableNames(m)
+	opts := make([]macpack.Option, 0, len(urls))
+	for _, u := range urls {
+		name, ok := names[u]
+		if !ok {
+			return fmt.Errorf("%s: missing in manifest", u)
+		}
+		opts = append(opts, macpack.WithLocalSource(filepath.Join(dir, name)))
+	}
+	mp, err := macpack.New(opts...)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), "."+filepath.Base(file)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	err = mp.WriteCSV(tmp)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// tableNames maps the urls of the manifest to the names of their tables.
+func tableNames(m *crawler.Manifest) map[string]string {
+	names := make(map[string]string, len(m.Files))
+	for _, f := range m.Files {
+		names[f.URL] = f.Name
+	}
+	return names
+}
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/frzifus/vlookup/pkg/crawler"
 	"github.com/frzifus/vlookup/pkg/macpack"
 	"github.com/frzifus/vlookup/pkg/version"
 )
@@ -18,12 +21,25 @@ func main() {
 		srcFetchMacLarge  = flag.Bool("src.fetch-l", false, "get large from ieee.org")
 		srcFetchMacMedium = flag.Bool("src.fetch-m", false, "get medium from ieee.org")
 		srcFetchMacSmall  = flag.Bool("src.fetch-s", false, "get small from ieee.org")
-		srcFetchAll       = flag.Bool("src.fetch-all", false, "get all from ieee.org")
+		srcFetchIAB       = flag.Bool("src.fetch-iab", false, "get iab from ieee.org")
+		srcFetchCID       = flag.Bool("src.fetch-cid", false, "get cid from ieee.org")
+		srcFetchAll       = flag.Bool("src.fetch-all", false, "get all registries from ieee.org")
+		srcFetchManuf     = flag.Bool("src.fetch-manuf", false, "get the wireshark manuf database, ieee.org tables take precedence")
 		srcFetchCustom    = flag.String("src.fetch-custom", "", "get small from ieee.org")
 
-		timeout = flag.Duration("timeout", 30*time.Second, "specified timeout")
+		timeout    = flag.Duration("timeout", 30*time.Second, "specified timeout")
+		store      = flag.String("o", "", "additionally store the fetched tables as <date>_<n>_<name>.csv in the current directory")
+		dir        = flag.String("dir", ".", "output directory, tables are stored by the base name of their url next to a manifest.json")
+		snapshots  = flag.String("snapshots", "snapshots", "keep a dated copy of every downloaded table in this directory below -dir, e.g. snapshots/2006-01-02_oui.csv. an empty value disables the copies")
+		exportFile = flag.String("export", "", "merge the fetched tables into one normalized csv file, e.g. vendors.csv")
+		retries    = flag.Int("retries", 3, "number of retries of a failed download")
+		backoff    = flag.Duration("backoff", time.Second, "delay before the first retry, doubles with every retry")
 
-		store = flag.String("o", "", "output file")
+		serve    = flag.String("serve", "", "serve the tables of the output directory at their ieee.org paths on the given address, e.g. :8080")
+		interval = flag.Duration("serve.interval", 24*time.Hour, "refresh the served tables in this interval, 0 disables the refresh")
+
+		diff       = flag.Bool("diff", false, "compare two snapshots given as arguments: crawler -diff old.csv new.csv")
+		diffFormat = flag.String("diff.format", "text", "output format of the diff. options: text, json")
 
 		printVersion = flag.Bool("version", false, "print version")
 	)
@@ -32,53 +48,139 @@ func main() {
 		fmt.Println(version.Version())
 		return
 	}
+	if *diff {
+		if flag.NArg() != 2 {
+			log.Fatalln("diff requires two snapshots: crawler -diff old.csv new.csv")
+		}
+		if err := runDiff(os.Stdout, flag.Arg(0), flag.Arg(1), *diffFormat); err != nil {
+			log.Fatalln(err)
+		}
+		return
+	}
 
 	if *srcFetchAll {
 		*srcFetchMacLarge, *srcFetchMacMedium, *srcFetchMacSmall = true, true, true
+		*srcFetchIAB, *srcFetchCID = true, true
 	}
-	urls := crawlURLS(*srcFetchMacLarge, *srcFetchMacMedium, *srcFetchMacSmall, *srcFetchCustom)
-	if len(urls) == 0 {
+	// manuf goes first, so the tables of the registry override it on export.
+	urls := crawlURLS([]fetch{
+		{*srcFetchManuf, macpack.RemoteWiresharkManuf},
+		{*srcFetchMacLarge, macpack.RemoteIeeeMACLarge},
+		{*srcFetchMacMedium, macpack.RemoteIeeeMACMedium},
+		{*srcFetchMacSmall, macpack.RemoteIeeeMACSmall},
+		{*srcFetchIAB, macpack.RemoteIeeeIAB},
+		{*srcFetchCID, macpack.RemoteIeeeCID},
+		{*srcFetchCustom != "", *srcFetchCustom},
+	})
+	if len(urls) == 0 && *serve == "" {
 		flag.PrintDefaults()
 		return
 	}
 
-	t := time.Now()
-	c := &http.Client{Timeout: *timeout}
-	for i, url := range urls {
-		log.Printf("%d) get: %s", i, url)
-		resp, err := c.Get(url)
-		if err != nil {
+	opts := []crawler.Option{
+		crawler.WithClient(&http.Client{Timeout: *timeout}),
+		crawler.WithRetries(*retries, *backoff),
+		crawler.WithLogger(log.Default()),
+	}
+	if *snapshots != "" {
+		opts = append(opts, crawler.WithSnapshots(filepath.Join(*dir, *snapshots)))
+	}
+	c := crawler.New(*dir, opts...)
+	if *serve != "" {
+		if err := runMirror(c, *dir, urls, *serve, *interval); err != nil {
 			log.Fatalln(err)
 		}
-		defer resp.Body.Close()
-		name := "unknown"
-		if *store != "" {
-			name = *store
-		}
-		f, err := os.Create(fmt.Sprintf("%s_%d_%s.csv", t.Format("2006-01-02"), i, name))
-		if err != nil {
+		return
+	}
+	m, err := c.Run(context.Background(), urls)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	for _, f := range m.Files {
+		log.Printf("%s: %d records, sha256 %s", f.Name, f.Records, f.SHA256)
+	}
+	if *store != "" {
+		if err := storeDated(*dir, m, urls, *store, time.Now()); err != nil {
 			log.Fatalln(err)
 		}
-		defer f.Close()
-		if _, err = io.Copy(f, resp.Body); err != nil {
+	}
+	if *exportFile != "" {
+		if err := export(*dir, m, urls, *exportFile); err != nil {
 			log.Fatalln(err)
 		}
+		log.Printf("exported to %s", *exportFile)
 	}
 }
 
-func crawlURLS(large, medium, small bool, custom string) []string {
-	var urls []string
-	if large {
-		urls = append(urls, macpack.RemoteIeeeMACLarge)
+// storeDated copies the tables of urls to <date>_<n>_<name>.csv in the
+// current directory, where n is the position of the url.
+func storeDated(dir string, m *crawler.Manifest, urls []string, name string, t time.Time) error {
+	names := tableNames(m)
+	for i, u := range urls {
+		table, ok := names[u]
+		if !ok {
+			return fmt.Errorf("%s: missing in manifest", u)
+		}
+		src, err := os.Open(filepath.Join(dir, table))
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+		dst, err := os.Create(fmt.Sprintf("%s_%d_%s.csv", t.Format("2006-01-02"), i, name))
+		if err != nil {
+			return err
+		}
+		defer dst.Close()
+		if _, err := io.Copy(dst, src); err != nil {
+			return err
+		}
+		if err := dst.Close(); err != nil {
+			return err
+		}
 	}
-	if medium {
-		urls = append(urls, macpack.RemoteIeeeMACMedium)
+	return nil
+}
+
+// runMirror serves the tables of dir on addr. If urls are given, the tables
+// are downloaded first and refreshed every interval.
+func runMirror(c *crawler.Crawler, dir string, urls []string, addr string, interval time.Duration) error {
+	if len(urls) > 0 {
+		// a failed download is not fatal as long as an older copy exists.
+		if _, err := c.Run(context.Background(), urls); err != nil {
+			log.Println(err)
+		}
+	}
+	mirror, err := crawler.NewMirror(dir)
+	if err != nil {
+		return err
 	}
-	if small {
-		urls = append(urls, macpack.RemoteIeeeMACSmall)
+	if len(urls) > 0 && interval > 0 {
+		go func() {
+			for range time.Tick(interval) {
+				if _, err := c.Run(context.Background(), urls); err != nil {
+					log.Println(err)
+				}
+				if err := mirror.Reload(); err != nil {
+					log.Println(err)
+				}
+			}
+		}()
 	}
-	if custom != "" {
-		urls = append(urls, custom)
+	log.Printf("serve %s on %s", dir, addr)
+	return http.ListenAndServe(addr, mirror)
+}
+
+type fetch struct {
+	enabled bool
+	url     string
+}
+
+func crawlURLS(fetches []fetch) []string {
+	var urls []string
+	for _, f := range fetches {
+		if f.enabled {
+			urls = append(urls, f.url)
+		}
 	}
 	return urls
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/frzifus/vlookup/pkg/macpack"
+	"github.com/frzifus/vlookup/pkg/version"
+)
+
+// tablegen compiles vendor tables into the binary index format embedded by
+// pkg/tables. Usage: tablegen -o oui.idx.gz oui.csv [more.csv ...]
+// If the output file ends with .gz, the index is gzip compressed.
+func main() {
+	var (
+		store = flag.String("o", "", "output file")
+
+		printVersion = flag.Bool("version", false, "print version")
+	)
+	flag.Parse()
+	if *printVersion {
+		fmt.Println(version.Version())
+		return
+	}
+	if *store == "" || flag.NArg() == 0 {
+		flag.PrintDefaults()
+		return
+	}
+
+	var opts []macpack.Option
+	for _, src := range flag.Args() {
+		opts = append(opts, macpack.WithLocalSource(src))
+	}
+	mp, err := macpack.New(opts...)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	f, err := os.Create(*store)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	w := bufio.NewWriter(f)
+	var zw *gzip.Writer
+	if strings.HasSuffix(*store, ".gz") {
+		// the header carries no name or time, so the output stays deterministic.
+		if zw, err = gzip.NewWriterLevel(w, gzip.BestCompression); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	var out io.Writer = w
+	if zw != nil {
+		out = zw
+	}
+	if err := mp.WriteIndex(out); err != nil {
+		log.Fatalln(err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalln(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatalln(err)
+	}
+	log.Printf("wrote %d vendor entries to %s\n", mp.Len(), *store)
+}
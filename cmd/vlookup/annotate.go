@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/frzifus/vlookup/pkg/macaddr"
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+// maxLineSize limits the length of a line read by annotate.
+const maxLineSize = 1 << 20
+
+// runAnnotate copies text from stdin to stdout and adds the vendor of every
+// hardware address found in it, e.g. to enrich syslog, dhcp logs or the
+// output of tcpdump -e.
+func runAnnotate(args []string) {
+	fs := flag.NewFlagSet("annotate", flag.ExitOnError)
+	var (
+		sources = addSourceFlags(fs)
+
+		mode = fs.String("mode", "inline", "inline: add the vendor behind each address, column: append the vendors to the line")
+		sep  = fs.String("sep", "\t", "separator of the column mode")
+		bare = fs.Bool("bare", false, "also match addresses written as 12 hex digits without separators")
+	)
+	fs.Parse(args)
+	if *mode != "inline" && *mode != "column" {
+		log.Fatalf("unknown mode: %q\n", *mode)
+	}
+
+	mp, err := sources.load()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	n := macaddr.NotationCommon
+	if *bare {
+		n |= macaddr.NotationBare
+	}
+	if err := annotate(mp, os.Stdin, os.Stdout, n, *mode == "column", *sep); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func annotate(mp *macpack.MacPack, r io.Reader, w io.Writer, n macaddr.Notation, column bool, sep string) error {
+	s := bufio.NewScanner(r)
+	s.Buffer(make([]byte, 64*1024), maxLineSize)
+	bw := bufio.NewWriter(w)
+	for s.Scan() {
+		line := s.Text()
+		var b strings.Builder
+		var names []string
+		last := 0
+		for _, m := range macaddr.FindAll(line, n) {
+			name := "not found"
+			if a := mp.Get(line[m[0]:m[1]]); a != nil {
+				name = a.Name
+			}
+			names = append(names, name)
+			if !column {
+				b.WriteString(line[last:m[1]])
+				fmt.Fprintf(&b, " (%s)", name)
+				last = m[1]
+			}
+		}
+		b.WriteString(line[last:])
+		if column && len(names) > 0 {
+			b.WriteString(sep)
+			b.WriteString(strings.Join(names, "; "))
+		}
+		b.WriteByte('\n')
+		if _, err := bw.WriteString(b.String()); err != nil {
+			return err
+		}
+		// flush every line, annotate is usually part of a pipeline.
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/frzifus/vlookup/pkg/macaddr"
+	"github.com/frzifus/vlookup/pkg/macpack"
+	"github.com/google/go-cmp/cmp"
+)
+
+const testVendors = `Registry,Assignment,Organization Name,Organization Address
+MA-L,00D0EF,IGT,9295 PROTOTYPE DRIVE RENO NV US 89511
+MA-L,001B21,Intel Corporate,Lot 8 Jalan Hi-Tech 2/3 Kulim Kedah MY 09000
+`
+
+func Test_annotate(t *testing.T) {
+	mp, err := macpack.New(macpack.WithReaderSource(strings.NewReader(testVendors)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const (
+		tcpdump = "12:00:00.000000 00:d0:ef:01:02:03 > ff:ff:ff:ff:ff:ff, ethertype ARP (0x0806), length 42: Request who-has 192.0.2.1 tell 192.0.2.2, length 28"
+		syslog  = "Oct 16 19:00:00 router dnsmasq-dhcp[123]: DHCPACK(eth0) 192.0.2.10 00-1B-21-0A-BC-DE laptop"
+		plain   = "Oct 16 19:00:01 router dnsmasq[123]: read /etc/hosts - 2 names"
+		bare    = "Oct 16 19:00:02 router hostapd: station 001b210abcde associated"
+	)
+	tests := []struct {
+		name     string
+		in       string
+		notation macaddr.Notation
+		column   bool
+		sep      string
+		want     string
+	}{
+		{
+			name:     "inline",
+			in:       tcpdump + "\n" + syslog + "\n" + plain + "\n",
+			notation: macaddr.NotationCommon,
+			want: "12:00:00.000000 00:d0:ef:01:02:03 (IGT) > ff:ff:ff:ff:ff:ff (not found), ethertype ARP (0x0806), length 42: Request who-has 192.0.2.1 tell 192.0.2.2, length 28\n" +
+				"Oct 16 19:00:00 router dnsmasq-dhcp[123]: DHCPACK(eth0) 192.0.2.10 00-1B-21-0A-BC-DE (Intel Corporate) laptop\n" +
+				plain + "\n",
+		},
+		{
+			name:     "column",
+			in:       tcpdump + "\n" + syslog + "\n" + plain + "\n",
+			notation: macaddr.NotationCommon,
+			column:   true,
+			sep:      "\t",
+			want: tcpdump + "\tIGT; not found\n" +
+				syslog + "\tIntel Corporate\n" +
+				plain + "\n",
+		},
+		{
+			name:     "column separator",
+			in:       syslog,
+			notation: macaddr.NotationCommon,
+			column:   true,
+			sep:      " | ",
+			want:     syslog + " | Intel Corporate\n",
+		},
+		{
+			name:     "bare ignored",
+			in:       bare,
+			notation: macaddr.NotationCommon,
+			want:     bare + "\n",
+		},
+		{
+			name:     "bare",
+			in:       bare,
+			notation: macaddr.NotationCommon | macaddr.NotationBare,
+			want:     "Oct 16 19:00:02 router hostapd: station 001b210abcde (Intel Corporate) associated\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := annotate(mp, strings.NewReader(tt.in), &out, tt.notation, tt.column, tt.sep); err != nil {
+				t.Fatal(err)
+			}
+			if got := out.String(); got != tt.want {
+				t.Error(cmp.Diff(got, tt.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/frzifus/vlookup/pkg/exporter"
+)
+
+// runExporter scans the network periodically and serves the devices as
+// Prometheus metrics, see package exporter.
+func runExporter(args []string) {
+	fs := flag.NewFlagSet("exporter", flag.ExitOnError)
+	var (
+		sources = addSourceFlags(fs)
+
+		listen     = fs.String("listen", ":9740", "address of the http server, metrics are served at /metrics")
+		labelsFile = fs.String("labels", "", "file of labels for addresses or prefixes, one \"<address>[/<bits>] <label>\" per line")
+		reload     = fs.Duration("reload.interval", 24*time.Hour, "reload the vendor sources in this interval, 0 disables the reload")
+
+		scanInterval = fs.Duration("scan.interval", time.Minute, "scan the network in this interval")
+		arpScan      = fs.Bool("arp.scan", true, "actively searches the network for other devices, this operation requires root privileges")
+		arpTimeout   = fs.Duration("arp.timeout", 10*time.Second, "time to wait for responses")
+		iface        = fs.String("i", "", "filter interface")
+	)
+	fs.Parse(args)
+	if *arpScan && *arpTimeout >= *scanInterval {
+		log.Fatalln("arp.timeout must be shorter than scan.interval")
+	}
+
+	labels, err := loadLabels(*labelsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	db, err := watchDB(ctx, sources, *reload)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	exp := exporter.New(db)
+	sc := &scanner{db: db, labels: labels, interval: *scanInterval, active: *arpScan, timeout: *arpTimeout, iface: *iface}
+	go sc.run(ctx, func(r scanRun) {
+		exp.Update(exporter.Scan{Time: r.start, Duration: r.duration, Replies: r.replies, Devices: r.rows})
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", exp)
+	if err := serveHTTP(ctx, *listen, mux); err != nil {
+		log.Fatalln(err)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/frzifus/vlookup/pkg/api"
+	"github.com/frzifus/vlookup/pkg/macaddr"
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+// runLookup resolves hardware addresses without scanning the network. The
+// addresses are taken from the arguments or, if there are none, from stdin
+// with one address per line.
+func runLookup(args []string) {
+	fs := flag.NewFlagSet("lookup", flag.ExitOnError)
+	var (
+		sources = addSourceFlags(fs)
+
+		trimAddress = fs.Int("trim.address", 40, "limits the length of the address field")
+		outFormat   = fs.String("format", "table", "output format: table, json")
+		labelsFile  = fs.String("labels", "", "file of labels for addresses or prefixes, one \"<address>[/<bits>] <label>\" per line")
+	)
+	fs.Parse(args)
+
+	mp, err := sources.load()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	labels, err := loadLabels(*labelsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	w, err := newRowWriter(os.Stdout, *outFormat, *trimAddress)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	var in io.Reader = strings.NewReader(strings.Join(fs.Args(), "\n"))
+	if fs.NArg() == 0 {
+		in = os.Stdin
+	}
+	if err := lookup(mp, labels, in, w); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+func lookup(mp *macpack.MacPack, labels *macpack.Labels, in io.Reader, w rowWriter) error {
+	s := bufio.NewScanner(in)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		mac, err := macaddr.Parse(line)
+		if err != nil {
+			log.Printf("skip: %v\n", err)
+			continue
+		}
+		if err := w.Write(api.Resolve(mp, labels, mac)); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return err
+	}
+	return w.Flush()
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/frzifus/vlookup/pkg/api"
+	"github.com/frzifus/vlookup/pkg/arp"
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+const (
+	format = "%-5s %-10s %-26s %-20s %-10s %-24s %-20s %-20s %-7s %-15s\n"
+)
+
+// row is a single device as printed by vlookup, the api serves the same.
+type row = api.Device
+
+// loadLabels reads the labels file of the -labels flag, an empty path means
+// no labels.
+func loadLabels(path string) (*macpack.Labels, error) {
+	if path == "" {
+		return nil, nil
+	}
+	return macpack.LoadLabels(path)
+}
+
+// rowWriter writes rows in one of the output formats.
+type rowWriter interface {
+	Write(r row) error
+	Flush() error
+}
+
+// newRowWriter returns a writer for the format "table" or "json". The json
+// format writes one object per line.
+func newRowWriter(w io.Writer, outFormat string, trimAddress int) (rowWriter, error) {
+	switch outFormat {
+	case "table":
+		return &tableWriter{w: w, trimAddress: trimAddress}, nil
+	case "json":
+		return &jsonWriter{enc: json.NewEncoder(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown output format: %q", outFormat)
+}
+
+type tableWriter struct {
+	w           io.Writer
+	trimAddress int
+	rows        int
+	headerDone  bool
+}
+
+func (t *tableWriter) header() error {
+	if t.headerDone {
+		return nil
+	}
+	t.headerDone = true
+	if _, err := fmt.Fprintf(t.w, format, "idx", "interface", "IP", "MAC", "State", "Class", "Name", "Label", "Country", "Address"); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(t.w, format, "---", "---------", "--", "---", "-----", "-----", "----", "-----", "-------", "-------")
+	return err
+}
+
+func (t *tableWriter) Write(r row) error {
+	if err := t.header(); err != nil {
+		return err
+	}
+	name, addr := r.Name, r.Address
+	if r.Prefix == "" && r.State != string(arp.StateIncomplete) {
+		name = "not found"
+	}
+	if r.Description != "" && r.Description != name {
+		name += " (" + r.Description + ")"
+	}
+	if len(addr) > t.trimAddress {
+		addr = addr[0:t.trimAddress]
+	}
+	_, err := fmt.Fprintf(t.w, format, strconv.Itoa(t.rows), r.Interface, r.IP, r.MAC, r.State, r.Class, name, r.Label, r.Country, addr)
+	t.rows++
+	return err
+}
+
+func (t *tableWriter) Flush() error {
+	return t.header()
+}
+
+type jsonWriter struct {
+	enc *json.Encoder
+}
+
+func (j *jsonWriter) Write(r row) error {
+	return j.enc.Encode(r)
+}
+
+func (j *jsonWriter) Flush() error {
+	return nil
+}
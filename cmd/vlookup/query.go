@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+const (
+	searchFormat = "%-50s %-8s %-15s\n"
+	prefixFormat = "%-24s %-10s %-12s %-40s\n"
+	statsFormat  = "%-20s %-12s %-8s %-15s\n"
+)
+
+// runSearch lists the vendors whose name matches the query.
+func runSearch(args []string) {
+	fs, sources, outFormat := queryFlags("search")
+	useRegexp := fs.Bool("regexp", false, "treat the query as regular expression")
+	fs.Parse(args)
+	mp, match := loadQuery(fs, sources, *useRegexp)
+
+	vendors := mp.Search(match)
+	if *outFormat == "json" {
+		type vendor struct {
+			Name      string `json:"name"`
+			Blocks    int    `json:"blocks"`
+			Addresses uint64 `json:"addresses"`
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, v := range vendors {
+			if err := enc.Encode(vendor{Name: v.Name, Blocks: len(v.Blocks), Addresses: v.Addresses()}); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		return
+	}
+	fmt.Printf(searchFormat, "Name", "Blocks", "Addresses")
+	fmt.Printf(searchFormat, "----", "------", "---------")
+	for _, v := range vendors {
+		fmt.Printf(searchFormat, v.Name, fmt.Sprint(len(v.Blocks)), fmt.Sprint(v.Addresses()))
+	}
+}
+
+// runPrefixes lists all blocks assigned to the vendors whose name matches
+// the query, e.g. to build layer 2 access lists.
+func runPrefixes(args []string) {
+	fs, sources, outFormat := queryFlags("prefixes")
+	useRegexp := fs.Bool("regexp", false, "treat the query as regular expression")
+	fs.Parse(args)
+	mp, match := loadQuery(fs, sources, *useRegexp)
+
+	var blocks []macpack.Assignment
+	for _, v := range mp.Search(match) {
+		blocks = append(blocks, v.Blocks...)
+	}
+	if *outFormat == "json" {
+		type block struct {
+			Prefix   string `json:"prefix"`
+			Registry string `json:"registry"`
+			Size     uint64 `json:"size"`
+			Name     string `json:"name"`
+			Source   string `json:"source"`
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, a := range blocks {
+			b := block{Prefix: prefixString(a.Prefix), Registry: string(a.Registry), Size: a.Prefix.Size(), Name: a.Name, Source: a.Source}
+			if err := enc.Encode(b); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		return
+	}
+	fmt.Printf(prefixFormat, "Prefix", "Registry", "Size", "Name")
+	fmt.Printf(prefixFormat, "------", "--------", "----", "----")
+	for _, a := range blocks {
+		fmt.Printf(prefixFormat, prefixString(a.Prefix), a.Registry, fmt.Sprint(a.Prefix.Size()), a.Name)
+	}
+}
+
+// runStats prints the number of blocks and addresses per source and
+// registry.
+func runStats(args []string) {
+	fs, sources, outFormat := queryFlags("stats")
+	fs.Parse(args)
+	mp, err := sources.load()
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	stats := mp.Stats()
+	if *outFormat == "json" {
+		type stat struct {
+			Source    string `json:"source"`
+			Registry  string `json:"registry"`
+			Blocks    int    `json:"blocks"`
+			Addresses uint64 `json:"addresses"`
+		}
+		enc := json.NewEncoder(os.Stdout)
+		for _, s := range stats {
+			if err := enc.Encode(stat{Source: s.Source, Registry: string(s.Registry), Blocks: s.Blocks, Addresses: s.Addresses}); err != nil {
+				log.Fatalln(err)
+			}
+		}
+		return
+	}
+	fmt.Printf(statsFormat, "Source", "Registry", "Blocks", "Addresses")
+	fmt.Printf(statsFormat, "------", "--------", "------", "---------")
+	for _, s := range stats {
+		fmt.Printf(statsFormat, s.Source, s.Registry, fmt.Sprint(s.Blocks), fmt.Sprint(s.Addresses))
+	}
+}
+
+func queryFlags(name string) (*flag.FlagSet, *sourceFlags, *string) {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	sources := addSourceFlags(fs)
+	outFormat := fs.String("format", "table", "output format: table, json")
+	return fs, sources, outFormat
+}
+
+func loadQuery(fs *flag.FlagSet, sources *sourceFlags, useRegexp bool) (*macpack.MacPack, macpack.Matcher) {
+	if fs.NArg() != 1 {
+		log.Fatalf("usage: vlookup %s [flags] <query>\n", fs.Name())
+	}
+	match := macpack.MatchSubstring(fs.Arg(0))
+	if useRegexp {
+		var err error
+		if match, err = macpack.MatchRegexp(fs.Arg(0)); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	mp, err := sources.load()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	return mp, match
+}
+
+// prefixString formats a prefix as address with mask, e.g.
+// 70:b3:d5:f2:f0:00/36.
+func prefixString(p macpack.Prefix) string {
+	return fmt.Sprintf("%s/%d", p.HardwareAddr(), p.Bits)
+}
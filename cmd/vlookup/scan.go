@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frzifus/vlookup/pkg/api"
+	"github.com/frzifus/vlookup/pkg/arp"
+	"github.com/frzifus/vlookup/pkg/macpack"
+	"github.com/frzifus/vlookup/pkg/version"
+)
+
+func runScan(args []string) {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	var (
+		sources = addSourceFlags(fs)
+
+		trimAddress = fs.Int("trim.address", 40, "limits the length of the address field")
+		labelsFile  = fs.String("labels", "", "file of labels for addresses or prefixes, one \"<address>[/<bits>] <label>\" per line")
+
+		filterCountry = fs.String("filter.country", "", "comma separated list of ISO country codes, only devices of vendors from these countries are listed")
+
+		arpScan    = fs.Bool("arp.scan", true, "actively searches the network for other devices, this operation requires root privileges")
+		arpTimeout = fs.Duration("arp.timeout", 10*time.Second, "time to wait for responses")
+
+		iface     = fs.String("i", "", "filter interface")
+		store     = fs.String("o", "", "output file")
+		outFormat = fs.String("format", "table", "output format: table, json")
+
+		printVersion = fs.Bool("version", false, "print version")
+	)
+	fs.Parse(args)
+	if *printVersion {
+		fmt.Println(version.Version())
+		return
+	}
+
+	opts, err := sources.options()
+	if err != nil {
+		log.Fatalln(err)
+	}
+	labels, err := loadLabels(*labelsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *arpTimeout)
+	defer cancel()
+	var scanResult []*arp.Entry
+	if *arpScan {
+		if scanResult, err = doScan(ctx, *iface); err != nil {
+			log.Fatalln(err)
+		}
+		log.Println("finished scan")
+	}
+
+	// NOTE: to improve performance, the comparison list should be updated in
+	// parallel with the network scan. Also the same context can be used for this.
+	mp, err := macpack.New(opts...)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	sources.report(mp)
+	log.Printf("check %d vendor entries\n", mp.Len())
+
+	var buf bytes.Buffer
+	w, err := newRowWriter(&buf, *outFormat, *trimAddress)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	countries := countrySet(*filterCountry)
+	for _, r := range devices(mp, labels, collect(scanResult), *iface) {
+		if countries != nil {
+			if _, ok := countries[r.Country]; !ok {
+				continue
+			}
+		}
+		if err := w.Write(r); err != nil {
+			log.Fatalln(err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		log.Fatalln(err)
+	}
+	var b io.Reader = &buf
+	if *store != "" {
+		f, err := os.Create(*store)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer f.Close()
+		b = io.TeeReader(b, f)
+	}
+	if _, err := io.Copy(os.Stdout, b); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// collect merges the neighbor table with the entries found by a scan.
+// Duplicates are removed, the scan result replaces the cache entry of an
+// address on the same interface.
+// TODO: move and hide in arp package
+func collect(scanResult []*arp.Entry) []*arp.Entry {
+	cache, err := arp.Cache()
+	if err != nil {
+		log.Println(err)
+	}
+	var entries []*arp.Entry
+	index := make(map[string]int)
+	for _, e := range append(cache, scanResult...) {
+		key := e.Address.String()
+		if e.Device != nil {
+			key += "%" + e.Device.Name
+		}
+		if i, ok := index[key]; ok {
+			entries[i] = e
+			continue
+		}
+		index[key] = len(entries)
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// devices resolves the entries of the interface iface, an empty name
+// selects all interfaces.
+func devices(mp *macpack.MacPack, labels *macpack.Labels, entries []*arp.Entry, iface string) []row {
+	rows := make([]row, 0, len(entries))
+	for _, e := range entries {
+		if iface != "" && e.Device != nil && e.Device.Name != iface {
+			continue
+		}
+		var r row
+		switch state := e.State(); state {
+		case arp.StateIncomplete:
+			// the address of an incomplete entry is all zeros, which would
+			// resolve to a vendor.
+			r = row{MAC: e.Mac.String(), State: string(state)}
+		default:
+			r = api.Resolve(mp, labels, e.Mac)
+			r.State = string(state)
+		}
+		r.Router = e.Router
+		r.Interface = "unknown"
+		if e.Device != nil {
+			r.Interface = e.Device.Name
+		}
+		r.IP = e.Address.String()
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// doScan sends arp requests on all interfaces, or only on use if it is set,
+// and collects the replies until ctx is done. It returns once all sockets
+// are closed.
+func doScan(ctx context.Context, use string) ([]*arp.Entry, error) {
+	if os.Geteuid() > 0 {
+		return nil, errors.New("user has insufficient permissions")
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	// stop the discoveries before waiting for them.
+	defer wg.Wait()
+	defer cancel()
+
+	hosts := make(chan arp.Entry)
+	errc := make(chan error)
+	var entries []*arp.Entry
+	for _, iface := range ifaces {
+		if use != "" && use != iface.Name {
+			continue
+		}
+
+		wg.Add(1)
+		go func(iface net.Interface) {
+			defer wg.Done()
+			if iface.Flags&(net.FlagLoopback|net.FlagPointToPoint) != 0 ||
+				iface.Flags&net.FlagUp == 0 {
+				log.Println("skip interface: ", iface.Name)
+				return
+			}
+
+			log.Println("start scan on interface", iface.Name)
+			d, err := arp.NewDiscovery(&iface)
+			if err != nil {
+				select {
+				case errc <- err:
+				case <-ctx.Done():
+				}
+				return
+			}
+			// Find blocks in reading replies, closing the socket ends it.
+			found := make(chan struct{})
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				select {
+				case <-ctx.Done():
+				case <-found:
+				}
+				d.Close()
+			}()
+			err = d.Find(ctx, hosts)
+			close(found)
+			if err != nil && ctx.Err() == nil {
+				select {
+				case errc <- err:
+				case <-ctx.Done():
+				}
+			}
+		}(iface)
+	}
+	for {
+		select {
+		case h := <-hosts:
+			entries = append(entries, &h)
+		case <-ctx.Done():
+			return entries, nil
+		case err := <-errc:
+			return nil, err
+		}
+	}
+}
+
+// countrySet parses a comma separated list of country codes. If the list is
+// empty, nil is returned.
+func countrySet(list string) map[string]struct{} {
+	var set map[string]struct{}
+	for _, c := range strings.Split(list, ",") {
+		if c = strings.ToUpper(strings.TrimSpace(c)); c == "" {
+			continue
+		}
+		if set == nil {
+			set = make(map[string]struct{})
+		}
+		set[c] = struct{}{}
+	}
+	return set
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/frzifus/vlookup/pkg/api"
+	"github.com/frzifus/vlookup/pkg/arp"
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+// runServe serves the vendor database and the devices of the network as
+// HTTP JSON API, see package api.
+func runServe(args []string) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	var (
+		sources = addSourceFlags(fs)
+
+		listen     = fs.String("listen", "127.0.0.1:8080", "address of the http server")
+		labelsFile = fs.String("labels", "", "file of labels for addresses or prefixes, one \"<address>[/<bits>] <label>\" per line")
+		reload     = fs.Duration("reload.interval", 24*time.Hour, "reload the vendor sources in this interval, 0 disables the reload")
+
+		scanInterval = fs.Duration("scan.interval", time.Minute, "update the inventory in this interval")
+		arpScan      = fs.Bool("arp.scan", false, "actively searches the network for other devices, this operation requires root privileges")
+		arpTimeout   = fs.Duration("arp.timeout", 10*time.Second, "time to wait for responses")
+		iface        = fs.String("i", "", "filter interface")
+	)
+	fs.Parse(args)
+
+	labels, err := loadLabels(*labelsFile)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	db, err := watchDB(ctx, sources, *reload)
+	if err != nil {
+		log.Fatalln(err)
+	}
+
+	srv := api.NewServer(db, labels)
+	sc := &scanner{db: db, labels: labels, interval: *scanInterval, active: *arpScan, timeout: *arpTimeout, iface: *iface}
+	go sc.run(ctx, func(r scanRun) {
+		srv.SetInventory(api.Inventory{Time: r.start, Devices: r.rows})
+	})
+	if err := serveHTTP(ctx, *listen, srv); err != nil {
+		log.Fatalln(err)
+	}
+}
+
+// watchDB loads the vendor database and reloads it on SIGHUP, when a local
+// source changes and in the given interval until ctx is done.
+func watchDB(ctx context.Context, sources *sourceFlags, interval time.Duration) (*macpack.DB, error) {
+	opts, err := sources.options()
+	if err != nil {
+		return nil, err
+	}
+	db, err := macpack.NewDB(opts...)
+	if err != nil {
+		return nil, err
+	}
+	sources.report(db.Get())
+	go db.Watch(ctx,
+		macpack.WatchSignals(syscall.SIGHUP),
+		macpack.WatchFiles(10*time.Second),
+		macpack.WatchInterval(interval),
+	)
+	return db, nil
+}
+
+// serveHTTP serves h on addr until ctx is done, running requests are given
+// a few seconds to finish.
+func serveHTTP(ctx context.Context, addr string, h http.Handler) error {
+	hs := &http.Server{Addr: addr, Handler: h}
+	errc := make(chan error, 1)
+	go func() {
+		log.Printf("serve on %s", addr)
+		errc <- hs.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	log.Println("shutting down")
+	sctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := hs.Shutdown(sctx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// scanner periodically collects the devices of the network, see run.
+type scanner struct {
+	db       *macpack.DB
+	labels   *macpack.Labels
+	interval time.Duration
+	// active enables the arp scan, otherwise only the arp cache is read.
+	active  bool
+	timeout time.Duration
+	iface   string
+}
+
+// scanRun is the outcome of a single scan.
+type scanRun struct {
+	start    time.Time
+	duration time.Duration
+	// replies counts the entries received by the active scan.
+	replies int
+	rows    []row
+}
+
+// run scans in the interval of the scanner until ctx is done and passes every
+// result to update.
+func (s *scanner) run(ctx context.Context, update func(scanRun)) {
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+	for {
+		update(s.scan(ctx))
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (s *scanner) scan(ctx context.Context) scanRun {
+	start := time.Now()
+	var result []*arp.Entry
+	if s.active {
+		ctx, cancel := context.WithTimeout(ctx, s.timeout)
+		defer cancel()
+		var err error
+		if result, err = doScan(ctx, s.iface); err != nil {
+			log.Println(err)
+		}
+	}
+	rows := devices(s.db.Get(), s.labels, collect(result), s.iface)
+	return scanRun{start: start, duration: time.Since(start), replies: len(result), rows: rows}
+}
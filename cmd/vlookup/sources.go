@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/frzifus/vlookup/pkg/macpack"
+	"github.com/frzifus/vlookup/pkg/tables"
+)
+
+const defaultSources = "embd-l,embd-m,embd-s,embd-wk"
+
+// sourceFlags are the flags shared by all commands to select the vendor
+// sources.
+type sourceFlags struct {
+	src       *string
+	localFile *string
+	timeout   *time.Duration
+	cacheDir  *string
+	cacheTTL  *time.Duration
+	noCache   *bool
+
+	precedence *string
+	conflicts  *bool
+	strict     *bool
+}
+
+func addSourceFlags(fs *flag.FlagSet) *sourceFlags {
+	return &sourceFlags{
+		src:       fs.String("src", defaultSources, "comma separated list of sources, -src.precedence decides which one wins a prefix defined by several. options: ieee-s, ieee-m, ieee-l, ieee-iab, ieee-cid, manuf, nmap, embd-s, embd-m, embd-l, embd-wk, file:<path>, http(s)://<url>"),
+		localFile: fs.String("src.local-file", "", "use file input"),
+		timeout:   fs.Duration("src.timeout", 30*time.Second, "timeout of remote sources"),
+		cacheDir:  fs.String("src.cache-dir", "", "cache directory of remote sources, defaults to vlookup in the user cache directory"),
+		cacheTTL:  fs.Duration("src.cache-ttl", 24*time.Hour, "use cached remote sources without revalidation for this duration"),
+		noCache:   fs.Bool("src.no-cache", false, "do not cache remote sources"),
+
+		precedence: fs.String("src.precedence", "last", "source that wins if sources define the same prefix differently. options: last, first"),
+		conflicts:  fs.Bool("src.conflicts", false, "report prefixes that are defined differently by the sources on stderr"),
+		strict:     fs.Bool("src.strict", false, "fail on malformed rows of csv sources instead of skipping them"),
+	}
+}
+
+func (s *sourceFlags) options() ([]macpack.Option, error) {
+	var remote []macpack.RemoteOption
+	p, err := macpack.ParsePrecedence(*s.precedence)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := srcOptions(*s.src, *s.localFile, func() []macpack.RemoteOption {
+		if remote == nil {
+			remote = s.remoteOptions()
+		}
+		return remote
+	})
+	if err != nil {
+		return nil, err
+	}
+	mode := macpack.ParseLenient
+	if *s.strict {
+		mode = macpack.ParseStrict
+	}
+	return append(opts,
+		macpack.WithPrecedence(p),
+		macpack.WithParseMode(mode),
+		macpack.WithDiagnostics(func(d macpack.Diagnostic) {
+			log.Printf("skip %v", d)
+		}),
+	), nil
+}
+
+// remoteOptions returns the options of the remote sources. A cache directory
+// that cannot be determined only disables the cache.
+func (s *sourceFlags) remoteOptions() []macpack.RemoteOption {
+	remote := []macpack.RemoteOption{macpack.RemoteClient(&http.Client{Timeout: *s.timeout})}
+	if *s.noCache {
+		return remote
+	}
+	dir := *s.cacheDir
+	if dir == "" {
+		var err error
+		if dir, err = macpack.DefaultCacheDir(); err != nil {
+			log.Printf("remote sources are not cached: %v", err)
+			return remote
+		}
+	}
+	return append(remote, macpack.RemoteCache(dir, *s.cacheTTL))
+}
+
+// load creates the vendor database of the selected sources.
+func (s *sourceFlags) load() (*macpack.MacPack, error) {
+	opts, err := s.options()
+	if err != nil {
+		return nil, err
+	}
+	mp, err := macpack.New(opts...)
+	if err != nil {
+		return nil, err
+	}
+	s.report(mp)
+	return mp, nil
+}
+
+// report logs remote sources that were not downloaded because of an error,
+// so the user knows the data may be outdated. If requested, the conflicts
+// between the sources are written to stderr.
+func (s *sourceFlags) report(mp *macpack.MacPack) {
+	for _, src := range mp.Sources() {
+		if src.Err != nil {
+			log.Printf("%s: using %s copy: %v", src.Name, src.Origin, src.Err)
+		}
+	}
+	if !*s.conflicts {
+		return
+	}
+	conflicts := mp.Conflicts()
+	fmt.Fprintf(os.Stderr, "%d conflicts\n", len(conflicts))
+	for _, c := range conflicts {
+		fmt.Fprintf(os.Stderr, "%s %s: %q from %s shadows %q from %s\n",
+			prefixString(c.Kept.Prefix), c.Kept.Registry,
+			c.Kept.Name, c.Kept.Source, c.Shadowed.Name, c.Shadowed.Source)
+	}
+}
+
+// srcOptions translates a comma separated list of sources into macpack
+// options. The sources are applied in the given order and a local file is
+// always applied last. Which entry wins a prefix defined by several sources is
+// decided by the precedence, see -src.precedence.
+// Supported sources:
+// - ieee-l, ieee-m, ieee-s, ieee-iab, ieee-cid: download the tables from ieee.org, ieee-l, ieee-m and ieee-s fall back to the embedded tables
+// - manuf, nmap: download the Wireshark or nmap vendor database
+// - embd-l, embd-m, embd-s: use the tables embedded into the binary
+// - embd-wk: use the embedded table of well-known ranges, e.g. VM guests
+// - file:/path/to/list.csv: use a local file, the format is detected
+// - http://... or https://...: download a table from a custom location
+// The remote options are applied to all downloads, they are only requested if
+// a remote source is selected.
+func srcOptions(sources string, local string, remote func() []macpack.RemoteOption) ([]macpack.Option, error) {
+	var opts []macpack.Option
+	for _, src := range strings.Split(sources, ",") {
+		src = strings.TrimSpace(src)
+		if src == "" {
+			continue
+		}
+		o, err := srcOption(src, remote)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, o)
+	}
+	if local != "" {
+		opts = append(opts, macpack.WithLocalSource(local))
+	}
+	if len(opts) == 0 {
+		return nil, errors.New("missing data source")
+	}
+	return opts, nil
+}
+
+func srcOption(src string, remote func() []macpack.RemoteOption) (macpack.Option, error) {
+	withRemote := func(url string, opts ...macpack.RemoteOption) macpack.Option {
+		return macpack.WithRemote(context.Background(), url, append(opts, remote()...)...)
+	}
+	switch {
+	case src == "ieee-l":
+		return withRemote(macpack.RemoteIeeeMACLarge, macpack.RemoteFallback(tables.Get(), tables.MACLarge)), nil
+	case src == "ieee-m":
+		return withRemote(macpack.RemoteIeeeMACMedium, macpack.RemoteFallback(tables.Get(), tables.MACMedium)), nil
+	case src == "ieee-s":
+		return withRemote(macpack.RemoteIeeeMACSmall, macpack.RemoteFallback(tables.Get(), tables.MACSmall)), nil
+	case src == "ieee-iab":
+		return withRemote(macpack.RemoteIeeeIAB), nil
+	case src == "ieee-cid":
+		return withRemote(macpack.RemoteIeeeCID), nil
+	case src == "manuf":
+		return withRemote(macpack.RemoteWiresharkManuf), nil
+	case src == "nmap":
+		return withRemote(macpack.RemoteNmapPrefixes), nil
+	case src == "embd-l":
+		return macpack.WithFSSource(tables.Get(), tables.MACLarge), nil
+	case src == "embd-m":
+		return macpack.WithFSSource(tables.Get(), tables.MACMedium), nil
+	case src == "embd-s":
+		return macpack.WithFSSource(tables.Get(), tables.MACSmall), nil
+	case src == "embd-wk":
+		return macpack.WithFSSource(tables.Get(), tables.WellKnown), nil
+	case strings.HasPrefix(src, "file:"):
+		return macpack.WithLocalSource(strings.TrimPrefix(src, "file:")), nil
+	case strings.HasPrefix(src, "http://"), strings.HasPrefix(src, "https://"):
+		return withRemote(src), nil
+	}
+	return nil, fmt.Errorf("unknown data source: %q", src)
+}
@@ -6,10 +6,10 @@ require (
 	github.com/google/go-cmp v0.6.0
 	github.com/mdlayher/arp v0.0.0-20191213142603-f72070a231fc
 	github.com/mdlayher/ethernet v0.0.0-20190606142754-0394541c37b7
+	golang.org/x/sys v0.18.0
 )
 
 require (
 	github.com/mdlayher/raw v0.0.0-20210412142147-51b895745faf // indirect
 	golang.org/x/net v0.23.0 // indirect
-	golang.org/x/sys v0.18.0 // indirect
 )
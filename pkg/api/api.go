@@ -0,0 +1,144 @@
+// Package api implements the HTTP JSON API served by "vlookup serve", so
+// tools written in other languages can resolve hardware addresses. All
+// responses are JSON objects:
+//
+//	GET  /api/v1/lookup/{mac}   resolve a single address, returns Device
+//	POST /api/v1/lookup         resolve a LookupRequest, returns LookupResponse
+//	GET  /api/v1/search?q=...   search vendors by name, returns SearchResponse
+//	GET  /api/v1/inventory      devices of the most recent scan, returns Inventory
+//	GET  /api/v1/status         state of the vendor database, returns Status
+//
+// The search matches substrings ignoring case, with regexp=true the query is
+// a regular expression. Failed requests are answered with an Error and a
+// 4xx or 5xx status code.
+package api
+
+import (
+	"net"
+	"time"
+
+	"github.com/frzifus/vlookup/pkg/macaddr"
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+// Device is a resolved hardware address. Interface, IP, State and Router are
+// only set for devices of the inventory. Fields of the vendor are empty if
+// the address is not assigned or the entry is incomplete.
+type Device struct {
+	Interface string `json:"interface,omitempty"`
+	IP        string `json:"ip,omitempty"`
+	MAC       string `json:"mac"`
+	// State of the arp entry: static, dynamic or incomplete.
+	State string `json:"state,omitempty"`
+	// Router is set for IPv6 neighbors that announced themselves as router.
+	Router bool `json:"router,omitempty"`
+	// Class is the classification of the address, e.g. "unicast,aai,random".
+	Class   string `json:"class"`
+	Name    string `json:"name,omitempty"`
+	Label   string `json:"label,omitempty"`
+	Country string `json:"country,omitempty"`
+	Address string `json:"address,omitempty"`
+	// Prefix holds the hex digits of the assignment, e.g. "f4bd9e".
+	Prefix   string `json:"prefix,omitempty"`
+	Registry string `json:"registry,omitempty"`
+	Kind     string `json:"kind,omitempty"`
+	// Description names the well-known range of the address, e.g.
+	// "VMware guest", it is set in addition to the vendor.
+	Description string `json:"description,omitempty"`
+}
+
+// Resolve classifies the hardware address and resolves its vendor and label.
+// A well-known range of the address sets the kind and the description, the
+// vendor is kept. Locally administered addresses that are known, e.g. those
+// of VM guests, are not reported as randomized. labels may be nil.
+func Resolve(mp *macpack.MacPack, labels *macpack.Labels, mac net.HardwareAddr) Device {
+	d := Device{MAC: mac.String()}
+	d.Label, _ = labels.Get(d.MAC)
+	class := macaddr.Classify(mac)
+	if a := mp.Get(d.MAC); a != nil {
+		d.Name, d.Country, d.Address = a.Name, a.Country, a.Address
+		d.Prefix, d.Registry, d.Kind = a.Prefix.String(), string(a.Registry), a.Kind.String()
+		class.Randomized = false
+	}
+	if p, err := macpack.ParsePrefix(d.MAC); err == nil {
+		if k, ok := mp.LookupWellKnown(p); ok {
+			d.Kind, d.Description = k.Kind.String(), k.Name
+		}
+	}
+	d.Class = class.String()
+	return d
+}
+
+// LookupRequest resolves several addresses at once, at most MaxBatch.
+type LookupRequest struct {
+	MACs []string `json:"macs"`
+}
+
+// MaxBatch limits the number of addresses of a LookupRequest.
+const MaxBatch = 10000
+
+// LookupResponse holds a result for every address of the request, in the
+// same order.
+type LookupResponse struct {
+	Results []LookupResult `json:"results"`
+}
+
+// LookupResult is either the resolved Device or the Error why the address
+// could not be parsed.
+type LookupResult struct {
+	Query  string  `json:"query"`
+	Device *Device `json:"device,omitempty"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// SearchResponse lists the matching vendors ordered by name.
+type SearchResponse struct {
+	Vendors []Vendor `json:"vendors"`
+}
+
+// Vendor is an organization and its blocks.
+type Vendor struct {
+	Name      string  `json:"name"`
+	Addresses uint64  `json:"addresses"`
+	Blocks    []Block `json:"blocks"`
+}
+
+// Block is an assignment of a vendor.
+type Block struct {
+	Prefix    string `json:"prefix"`
+	Bits      int    `json:"bits"`
+	Registry  string `json:"registry,omitempty"`
+	Source    string `json:"source"`
+	Addresses uint64 `json:"addresses"`
+}
+
+// Inventory is the result of the most recent scan. Time is zero until the
+// first scan finished.
+type Inventory struct {
+	Time    time.Time `json:"time"`
+	Devices []Device  `json:"devices"`
+}
+
+// Status describes the vendor database.
+type Status struct {
+	Loaded  time.Time `json:"loaded"`
+	Checked time.Time `json:"checked"`
+	Reloads int       `json:"reloads"`
+	// Error of the last reload, the previous database stays in use.
+	Error       string    `json:"error,omitempty"`
+	Assignments int       `json:"assignments"`
+	Sources     []Source  `json:"sources"`
+	Inventory   time.Time `json:"inventory"`
+}
+
+// Source is a source of the vendor database and the copy that was used.
+type Source struct {
+	Name   string `json:"name"`
+	Origin string `json:"origin"`
+	Error  string `json:"error,omitempty"`
+}
+
+// Error is returned for failed requests.
+type Error struct {
+	Error string `json:"error"`
+}
@@ -0,0 +1,185 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/frzifus/vlookup/pkg/macaddr"
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+// maxBody limits the size of request bodies.
+const maxBody = 1 << 20
+
+// Server serves the API, see the package documentation for the endpoints.
+type Server struct {
+	db     *macpack.DB
+	labels *macpack.Labels
+	mux    *http.ServeMux
+
+	mu        sync.RWMutex
+	inventory Inventory
+}
+
+// NewServer creates a Server for the database, labels may be nil.
+func NewServer(db *macpack.DB, labels *macpack.Labels) *Server {
+	s := &Server{db: db, labels: labels, mux: http.NewServeMux()}
+	s.inventory.Devices = []Device{}
+	s.mux.HandleFunc("/api/v1/lookup", s.handleBatch)
+	s.mux.HandleFunc("/api/v1/lookup/", s.handleLookup)
+	s.mux.HandleFunc("/api/v1/search", s.handleSearch)
+	s.mux.HandleFunc("/api/v1/inventory", s.handleInventory)
+	s.mux.HandleFunc("/api/v1/status", s.handleStatus)
+	return s
+}
+
+// SetInventory replaces the devices served by /api/v1/inventory.
+func (s *Server) SetInventory(inv Inventory) {
+	if inv.Devices == nil {
+		inv.Devices = []Device{}
+	}
+	s.mu.Lock()
+	s.inventory = inv
+	s.mu.Unlock()
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+func (s *Server) handleLookup(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	mac, err := macaddr.Parse(strings.TrimPrefix(r.URL.Path, "/api/v1/lookup/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, Resolve(s.db.Get(), s.labels, mac))
+}
+
+func (s *Server) handleBatch(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodPost) {
+		return
+	}
+	var req LookupRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request: "+err.Error())
+		return
+	}
+	if len(req.MACs) > MaxBatch {
+		writeError(w, http.StatusRequestEntityTooLarge, "at most "+strconv.Itoa(MaxBatch)+" addresses per request")
+		return
+	}
+	// all addresses are resolved against the same snapshot.
+	mp := s.db.Get()
+	resp := LookupResponse{Results: make([]LookupResult, 0, len(req.MACs))}
+	for _, q := range req.MACs {
+		res := LookupResult{Query: q}
+		if mac, err := macaddr.Parse(q); err != nil {
+			res.Error = err.Error()
+		} else {
+			d := Resolve(mp, s.labels, mac)
+			res.Device = &d
+		}
+		resp.Results = append(resp.Results, res)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleSearch(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query().Get("q")
+	if q == "" {
+		writeError(w, http.StatusBadRequest, "missing query parameter q")
+		return
+	}
+	match := macpack.MatchSubstring(q)
+	if re, _ := strconv.ParseBool(r.URL.Query().Get("regexp")); re {
+		var err error
+		if match, err = macpack.MatchRegexp(q); err != nil {
+			writeError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	resp := SearchResponse{Vendors: []Vendor{}}
+	for _, v := range s.db.Get().Search(match) {
+		vendor := Vendor{Name: v.Name, Addresses: v.Addresses()}
+		for _, b := range v.Blocks {
+			vendor.Blocks = append(vendor.Blocks, Block{
+				Prefix:    b.Prefix.String(),
+				Bits:      b.Prefix.Bits,
+				Registry:  string(b.Registry),
+				Source:    b.Source,
+				Addresses: b.Prefix.Size(),
+			})
+		}
+		resp.Vendors = append(resp.Vendors, vendor)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+func (s *Server) handleInventory(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	s.mu.RLock()
+	inv := s.inventory
+	s.mu.RUnlock()
+	writeJSON(w, http.StatusOK, inv)
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !allow(w, r, http.MethodGet) {
+		return
+	}
+	st := s.db.Status()
+	resp := Status{
+		Loaded:      st.Loaded,
+		Checked:     st.Checked,
+		Reloads:     st.Reloads,
+		Assignments: st.Len,
+		Sources:     []Source{},
+	}
+	if st.Err != nil {
+		resp.Error = st.Err.Error()
+	}
+	for _, src := range st.Sources {
+		res := Source{Name: src.Name, Origin: string(src.Origin)}
+		if src.Err != nil {
+			res.Error = src.Err.Error()
+		}
+		resp.Sources = append(resp.Sources, res)
+	}
+	s.mu.RLock()
+	resp.Inventory = s.inventory.Time
+	s.mu.RUnlock()
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// allow answers requests with another method with 405 Method Not Allowed.
+func allow(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method || method == http.MethodGet && r.Method == http.MethodHead {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, Error{Error: msg})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frzifus/vlookup/pkg/macpack"
+	"github.com/google/go-cmp/cmp"
+)
+
+func testServer(t *testing.T) *Server {
+	db, err := macpack.NewDB(macpack.WithReaderSource(strings.NewReader(
+		`Registry,Assignment,Organization Name,Organization Address
+MA-L,00D0EF,IGT,9295 PROTOTYPE DRIVE RENO NV US 89511
+MA-L,F4BD9E,"Cisco Systems, Inc",80 West Tasman Drive San Jose CA US 94568
+MA-S,70B3D5719,Cisco Systems Inc,
+MA-L,005056,"VMware, Inc.",3401 Hillview Avenue PALO ALTO CA US 94304`)), macpack.WithReaderSource(strings.NewReader(
+		`Prefix,Type,Name
+00:50:56,virtual-nic,VMware guest
+52:54:00,virtual-nic,KVM guest`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	labels, err := macpack.ParseLabels(strings.NewReader("f4:bd:9e:00:00:01 lab switch\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(db, labels)
+}
+
+func TestServer(t *testing.T) {
+	s := testServer(t)
+	s.SetInventory(Inventory{
+		Time:    time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+		Devices: []Device{{Interface: "eth0", IP: "192.168.1.2", MAC: "00:d0:ef:00:00:01", Class: "unicast", Name: "IGT"}},
+	})
+	cisco := Device{
+		MAC: "f4:bd:9e:00:00:01", Class: "unicast", Name: "Cisco Systems, Inc", Label: "lab switch", Country: "US",
+		Address: "80 West Tasman Drive San Jose CA US 94568", Prefix: "f4bd9e", Registry: "MA-L", Kind: "vendor",
+	}
+	random := Device{MAC: "02:00:00:00:00:01", Class: "unicast,aai,random"}
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		status int
+		got    interface{}
+		want   interface{}
+	}{
+		{
+			name: "lookup", target: "/api/v1/lookup/F4-BD-9E-00-00-01",
+			status: http.StatusOK, got: &Device{}, want: &cisco,
+		},
+		{
+			name: "lookup unknown", target: "/api/v1/lookup/020000000001",
+			status: http.StatusOK, got: &Device{}, want: &random,
+		},
+		{
+			name: "lookup well-known", target: "/api/v1/lookup/00:50:56:00:00:01",
+			status: http.StatusOK, got: &Device{}, want: &Device{
+				MAC: "00:50:56:00:00:01", Class: "unicast", Name: "VMware, Inc.", Country: "US",
+				Address: "3401 Hillview Avenue PALO ALTO CA US 94304", Prefix: "005056", Registry: "MA-L",
+				Kind: "virtual-nic", Description: "VMware guest",
+			},
+		},
+		{
+			name: "lookup well-known only", target: "/api/v1/lookup/52:54:00:00:00:01",
+			status: http.StatusOK, got: &Device{}, want: &Device{
+				MAC: "52:54:00:00:00:01", Class: "unicast,aai", Name: "KVM guest", Prefix: "525400",
+				Registry: "Well-Known", Kind: "virtual-nic", Description: "KVM guest",
+			},
+		},
+		{
+			name: "lookup invalid", target: "/api/v1/lookup/nope",
+			status: http.StatusBadRequest, got: &Error{},
+		},
+		{
+			name: "batch", method: http.MethodPost, target: "/api/v1/lookup",
+			body:   `{"macs": ["f4:bd:9e:00:00:01", "nope", "02:00:00:00:00:01"]}`,
+			status: http.StatusOK, got: &LookupResponse{},
+			want: &LookupResponse{Results: []LookupResult{
+				{Query: "f4:bd:9e:00:00:01", Device: &cisco},
+				{Query: "nope", Error: `invalid hardware address "nope"`},
+				{Query: "02:00:00:00:00:01", Device: &random},
+			}},
+		},
+		{
+			name: "batch invalid", method: http.MethodPost, target: "/api/v1/lookup",
+			body: `{"macs": "f4:bd:9e:00:00:01"}`, status: http.StatusBadRequest, got: &Error{},
+		},
+		{
+			name: "batch method", target: "/api/v1/lookup",
+			status: http.StatusMethodNotAllowed, got: &Error{},
+			want: &Error{Error: "method not allowed"},
+		},
+		{
+			name: "search", target: "/api/v1/search?q=cisco+systems",
+			status: http.StatusOK, got: &SearchResponse{},
+			want: &SearchResponse{Vendors: []Vendor{
+				{Name: "Cisco Systems Inc", Addresses: 4096, Blocks: []Block{
+					{Prefix: "70b3d5719", Bits: 36, Registry: "MA-S", Source: "reader", Addresses: 4096},
+				}},
+				{Name: "Cisco Systems, Inc", Addresses: 1 << 24, Blocks: []Block{
+					{Prefix: "f4bd9e", Bits: 24, Registry: "MA-L", Source: "reader", Addresses: 1 << 24},
+				}},
+			}},
+		},
+		{
+			name: "search regexp", target: "/api/v1/search?q=^IG&regexp=true",
+			status: http.StatusOK, got: &SearchResponse{},
+			want: &SearchResponse{Vendors: []Vendor{
+				{Name: "IGT", Addresses: 1 << 24, Blocks: []Block{
+					{Prefix: "00d0ef", Bits: 24, Registry: "MA-L", Source: "reader", Addresses: 1 << 24},
+				}},
+			}},
+		},
+		{
+			name: "search missing query", target: "/api/v1/search",
+			status: http.StatusBadRequest, got: &Error{},
+			want: &Error{Error: "missing query parameter q"},
+		},
+		{
+			name: "inventory", target: "/api/v1/inventory",
+			status: http.StatusOK, got: &Inventory{},
+			want: &Inventory{
+				Time:    time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC),
+				Devices: []Device{{Interface: "eth0", IP: "192.168.1.2", MAC: "00:d0:ef:00:00:01", Class: "unicast", Name: "IGT"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			rec := httptest.NewRecorder()
+			s.ServeHTTP(rec, httptest.NewRequest(method, tt.target, strings.NewReader(tt.body)))
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body)
+			}
+			if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+				t.Errorf("content type %q", ct)
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), tt.got); err != nil {
+				t.Fatal(err)
+			}
+			if tt.want != nil && !cmp.Equal(tt.got, tt.want) {
+				t.Error(cmp.Diff(tt.got, tt.want))
+			}
+		})
+	}
+}
+
+func TestServer_Status(t *testing.T) {
+	s := testServer(t)
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/status", nil))
+	var got Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Assignments != 4 || got.Loaded.IsZero() || !got.Inventory.IsZero() {
+		t.Errorf("Status = %+v", got)
+	}
+	if want := []Source{{Name: "reader", Origin: "local"}, {Name: "reader", Origin: "local"}}; !cmp.Equal(got.Sources, want) {
+		t.Error(cmp.Diff(got.Sources, want))
+	}
+}
@@ -2,9 +2,9 @@ package arp
 
 import (
 	"bufio"
-	"encoding/hex"
 	"io"
 	"net"
+	"strconv"
 	"strings"
 )
 
@@ -18,15 +18,112 @@ const (
 	columnBound
 )
 
+// HardwareType is the type of the link layer, see ARPHRD_* in
+// linux/if_arp.h.
+type HardwareType uint16
+
+// Common hardware types.
+const (
+	HardwareEthernet   HardwareType = 1
+	HardwareIEEE802    HardwareType = 6
+	HardwareInfiniband HardwareType = 32
+)
+
+func (t HardwareType) String() string {
+	switch t {
+	case HardwareEthernet:
+		return "ethernet"
+	case HardwareIEEE802:
+		return "ieee802"
+	case HardwareInfiniband:
+		return "infiniband"
+	}
+	return "0x" + strconv.FormatUint(uint64(t), 16)
+}
+
+// Flags of an arp cache entry, see ATF_* in linux/if_arp.h.
+type Flags uint8
+
+// Flags used by the kernel.
+const (
+	// FlagComplete is set once the hardware address has been resolved.
+	FlagComplete Flags = 0x02
+	// FlagPermanent marks static entries, e.g. added by "ip neigh add".
+	FlagPermanent Flags = 0x04
+	// FlagPublished marks proxy entries, the host answers for the address.
+	FlagPublished Flags = 0x08
+)
+
+// String returns the set flags separated by comma, e.g. "complete,permanent".
+func (f Flags) String() string {
+	var s []string
+	for _, n := range []struct {
+		flag Flags
+		name string
+	}{
+		{FlagComplete, "complete"},
+		{FlagPermanent, "permanent"},
+		{FlagPublished, "published"},
+	} {
+		if f&n.flag != 0 {
+			s = append(s, n.name)
+			f &^= n.flag
+		}
+	}
+	if f != 0 {
+		s = append(s, "0x"+strconv.FormatUint(uint64(f), 16))
+	}
+	return strings.Join(s, ",")
+}
+
+// State of an entry as shown by vlookup.
+type State string
+
+// States of an entry.
+const (
+	// StateIncomplete entries are still waiting for a reply, their hardware
+	// address is 00:00:00:00:00:00.
+	StateIncomplete State = "incomplete"
+	// StateDynamic entries were learned from a reply and expire.
+	StateDynamic State = "dynamic"
+	// StateStatic entries were configured and do not expire.
+	StateStatic State = "static"
+)
+
 // Entry represents an entry in the arp cache.
 // This can usually be found under linux under "/proc/net/arp".
 type Entry struct {
 	Address net.IP
-	Type    byte
-	Flags   byte
+	Type    HardwareType
+	Flags   Flags
 	Mac     net.HardwareAddr
 	Mask    string
 	Device  *net.Interface
+	// NUD is the state of entries read from the neighbor table, the Flags
+	// are derived from it. It is zero for entries of other sources.
+	NUD NUDState
+	// Router is set for neighbors that announced themselves as IPv6 router.
+	Router bool
+}
+
+// State returns whether the entry is incomplete, static or dynamic.
+func (e *Entry) State() State {
+	switch {
+	case isZero(e.Mac) || e.Flags&(FlagComplete|FlagPermanent) == 0:
+		return StateIncomplete
+	case e.Flags&FlagPermanent != 0:
+		return StateStatic
+	}
+	return StateDynamic
+}
+
+func isZero(mac net.HardwareAddr) bool {
+	for _, b := range mac {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
 }
 
 // ParseEntries parses s as an arp cache entry, returning the result.
@@ -47,11 +144,11 @@ func ParseEntries(r io.Reader) []*Entry {
 			continue
 		}
 		e := &Entry{Address: net.ParseIP(f[columnIPAddr]), Mask: f[columnMask]}
-		if t, err := hex.DecodeString(f[columnHWType]); err == nil && len(t) >= 0 {
-			e.Type = t[0]
+		if t, err := strconv.ParseUint(f[columnHWType], 0, 16); err == nil {
+			e.Type = HardwareType(t)
 		}
-		if fl, err := hex.DecodeString(f[columnFlags]); err == nil && len(fl) >= 0 {
-			e.Flags = fl[0]
+		if fl, err := strconv.ParseUint(f[columnFlags], 0, 8); err == nil {
+			e.Flags = Flags(fl)
 		}
 		if mac, err := net.ParseMAC(f[columnHWAddr]); err == nil {
 			e.Mac = mac
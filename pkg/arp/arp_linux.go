@@ -9,13 +9,15 @@ import (
 )
 
 // FromCache returns "/proc/net/arp" as io.Reader
-func FromCache() io.Reader {
+func FromCache() (io.Reader, error) {
 	f, err := os.Open("/proc/net/arp")
 	if err != nil {
-		return nil
+		return nil, err
 	}
 	defer f.Close()
 	var b bytes.Buffer
-	io.Copy(&b, f)
-	return &b
+	if _, err := io.Copy(&b, f); err != nil {
+		return nil, err
+	}
+	return &b, nil
 }
@@ -34,16 +34,69 @@ func TestParseEntries(t *testing.T) {
 			want: []*Entry{
 				{
 					Address: net.ParseIP("192.168.1.1"),
+					Type:    HardwareEthernet,
+					Flags:   FlagComplete,
 					Mac:     mac1,
 					Mask:    "*",
 				},
 				{
 					Address: net.ParseIP("192.168.1.2"),
+					Type:    HardwareEthernet,
+					Flags:   FlagComplete,
 					Mac:     mac2,
 					Mask:    "*",
 				},
 			},
 		},
+		{
+			// taken from hosts with a wifi, a libvirt bridge with a static
+			// entry, a proxy entry and an infiniband interface.
+			name: "proc net arp",
+			r: bytes.NewBuffer([]byte(
+				`IP address       HW type     Flags       HW address            Mask     Device
+192.168.178.1    0x1         0x2         3c:a6:2f:12:34:56     *        wlp2s0
+192.168.178.57   0x1         0x0         00:00:00:00:00:00     *        wlp2s0
+192.168.122.10   0x1         0x6         52:54:00:ab:cd:ef     *        virbr0
+192.168.122.99   0x1         0xc         52:54:00:12:34:56     *        virbr0
+10.1.0.2         0x20        0x2         80:00:02:08:fe:80:00:00:00:00:00:00:00:02:c9:03:00:0a:bc:de     *        ib0
+`)),
+			want: []*Entry{
+				{
+					Address: net.ParseIP("192.168.178.1"),
+					Type:    HardwareEthernet,
+					Flags:   FlagComplete,
+					Mac:     mustParseMAC(t, "3c:a6:2f:12:34:56"),
+					Mask:    "*",
+				},
+				{
+					Address: net.ParseIP("192.168.178.57"),
+					Type:    HardwareEthernet,
+					Mac:     mustParseMAC(t, "00:00:00:00:00:00"),
+					Mask:    "*",
+				},
+				{
+					Address: net.ParseIP("192.168.122.10"),
+					Type:    HardwareEthernet,
+					Flags:   FlagComplete | FlagPermanent,
+					Mac:     mustParseMAC(t, "52:54:00:ab:cd:ef"),
+					Mask:    "*",
+				},
+				{
+					Address: net.ParseIP("192.168.122.99"),
+					Type:    HardwareEthernet,
+					Flags:   FlagPermanent | FlagPublished,
+					Mac:     mustParseMAC(t, "52:54:00:12:34:56"),
+					Mask:    "*",
+				},
+				{
+					Address: net.ParseIP("10.1.0.2"),
+					Type:    HardwareInfiniband,
+					Flags:   FlagComplete,
+					Mac:     mustParseMAC(t, "80:00:02:08:fe:80:00:00:00:00:00:00:00:02:c9:03:00:0a:bc:de"),
+					Mask:    "*",
+				},
+			},
+		},
 		{
 			name: "empty cache",
 			r: bytes.NewBuffer([]byte(
@@ -65,11 +118,15 @@ func TestParseEntries(t *testing.T) {
 				`)),
 			want: []*Entry{
 				{
-					Mac:  mac1,
-					Mask: "*",
+					Type:  HardwareEthernet,
+					Flags: FlagComplete,
+					Mac:   mac1,
+					Mask:  "*",
 				},
 				{
 					Address: net.ParseIP("192.168.1.2"),
+					Type:    HardwareEthernet,
+					Flags:   FlagComplete,
 					Mask:    "*",
 				},
 			},
@@ -77,9 +134,83 @@ func TestParseEntries(t *testing.T) {
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			if got := ParseEntries(tc.r); !cmp.Equal(got, tc.want) {
+			got := ParseEntries(tc.r)
+			// the interfaces of the samples may exist on the test machine.
+			for _, e := range got {
+				e.Device = nil
+			}
+			if !cmp.Equal(got, tc.want) {
 				t.Error(cmp.Diff(got, tc.want))
 			}
 		})
 	}
 }
+
+func TestEntry_State(t *testing.T) {
+	tt := []struct {
+		name  string
+		flags Flags
+		mac   string
+		want  State
+	}{
+		{name: "dynamic", flags: FlagComplete, mac: "3c:a6:2f:12:34:56", want: StateDynamic},
+		{name: "static", flags: FlagComplete | FlagPermanent, mac: "52:54:00:ab:cd:ef", want: StateStatic},
+		{name: "incomplete", flags: 0, mac: "00:00:00:00:00:00", want: StateIncomplete},
+		{name: "zero address", flags: FlagComplete, mac: "00:00:00:00:00:00", want: StateIncomplete},
+		{name: "published", flags: FlagPermanent | FlagPublished, mac: "52:54:00:12:34:56", want: StateStatic},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			e := &Entry{Flags: tc.flags, Mac: mustParseMAC(t, tc.mac)}
+			if got := e.State(); got != tc.want {
+				t.Errorf("State() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFlags_String(t *testing.T) {
+	tt := []struct {
+		flags Flags
+		want  string
+	}{
+		{flags: 0, want: ""},
+		{flags: 0x2, want: "complete"},
+		{flags: 0x6, want: "complete,permanent"},
+		{flags: 0xc, want: "permanent,published"},
+		{flags: 0x42, want: "complete,0x40"},
+	}
+	for _, tc := range tt {
+		if got := tc.flags.String(); got != tc.want {
+			t.Errorf("Flags(%#x).String() = %q, want %q", uint8(tc.flags), got, tc.want)
+		}
+	}
+}
+
+func TestNUDState_String(t *testing.T) {
+	tt := []struct {
+		state NUDState
+		want  string
+	}{
+		{state: 0, want: "none"},
+		{state: NUDReachable, want: "reachable"},
+		{state: NUDStale, want: "stale"},
+		{state: NUDFailed, want: "failed"},
+		{state: NUDPermanent, want: "permanent"},
+		{state: NUDIncomplete | 0x100, want: "incomplete,0x100"},
+	}
+	for _, tc := range tt {
+		if got := tc.state.String(); got != tc.want {
+			t.Errorf("NUDState(%#x).String() = %q, want %q", uint16(tc.state), got, tc.want)
+		}
+	}
+}
+
+func mustParseMAC(t *testing.T, s string) net.HardwareAddr {
+	t.Helper()
+	mac, err := net.ParseMAC(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return mac
+}
@@ -36,6 +36,7 @@ func NewDiscovery(iface *net.Interface, opts ...Option) (*Discovery, error) {
 	}
 	addresses, err := iface.Addrs()
 	if err != nil {
+		c.Close()
 		return nil, err
 	}
 
@@ -43,6 +44,7 @@ func NewDiscovery(iface *net.Interface, opts ...Option) (*Discovery, error) {
 	for _, a := range addresses {
 		t, err := hosts(a.String())
 		if err != nil {
+			c.Close()
 			return nil, err
 		}
 		targets = append(targets, t...)
@@ -113,11 +115,19 @@ func (a *Discovery) Close() error {
 // Find device entries in the network where the initialized interface is
 // located. This method blocks and returns the results via the passed entry
 // channel. The process can be terminated by canceling the passed context.
+// Since reading a reply blocks until one arrives, the Discovery should be
+// closed once the context is done.
 func (a *Discovery) Find(ctx context.Context, response chan<- Entry) error {
 	ctx, cancel := context.WithCancel(ctx)
-	defer cancel()
-	go a.scan(ctx)
-	return a.receive(ctx, response)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		a.scan(ctx)
+	}()
+	err := a.receive(ctx, response)
+	cancel()
+	<-done
+	return err
 }
 
 func (a *Discovery) scan(ctx context.Context) {
@@ -158,14 +168,19 @@ func (a *Discovery) receive(ctx context.Context, response chan<- Entry) error {
 		}
 
 		for _, ip := range a.myAddresses {
-			if !resp.SenderIP.Equal(ip) {
-				response <- Entry{
-					Address: resp.SenderIP,
-					Type:    byte(resp.HardwareType),
-					Flags:   byte(resp.ProtocolType),
-					Mac:     resp.SenderHardwareAddr,
-					Device:  a.iface,
-				}
+			if resp.SenderIP.Equal(ip) {
+				continue
+			}
+			select {
+			case response <- Entry{
+				Address: resp.SenderIP,
+				Type:    HardwareType(resp.HardwareType),
+				Flags:   FlagComplete,
+				Mac:     resp.SenderHardwareAddr,
+				Device:  a.iface,
+			}:
+			case <-ctx.Done():
+				return nil
 			}
 		}
 	}
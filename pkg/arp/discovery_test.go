@@ -0,0 +1,91 @@
+package arp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mdlayher/arp"
+	"github.com/mdlayher/ethernet"
+)
+
+// testClient answers the first reads with a reply, after the replies are
+// used up, reads block like on a quiet network until the client is closed.
+// replied is closed once the last reply has been read.
+type testClient struct {
+	replies int
+	replied chan struct{}
+	once    sync.Once
+	closed  chan struct{}
+}
+
+func (c *testClient) Request(net.IP) error             { return nil }
+func (c *testClient) SetWriteDeadline(time.Time) error { return nil }
+
+func (c *testClient) Read() (*arp.Packet, *ethernet.Frame, error) {
+	if c.replies > 0 {
+		if c.replies--; c.replies == 0 {
+			close(c.replied)
+		}
+		return &arp.Packet{
+			Operation:          arp.OperationReply,
+			SenderIP:           net.IPv4(192, 0, 2, 2),
+			SenderHardwareAddr: net.HardwareAddr{0x00, 0xd0, 0xef, 0x01, 0x02, 0x03},
+		}, nil, nil
+	}
+	<-c.closed
+	return nil, nil, errors.New("use of closed socket")
+}
+
+func (c *testClient) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func TestDiscovery_Find(t *testing.T) {
+	tests := []struct {
+		name    string
+		replies int
+		close   bool
+		wantErr bool
+	}{
+		{name: "reply not received", replies: 1},
+		{name: "closed while reading", close: true, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &testClient{replies: tt.replies, replied: make(chan struct{}), closed: make(chan struct{})}
+			d := &Discovery{
+				client:      c,
+				myAddresses: []net.IP{net.IPv4(192, 0, 2, 1)},
+				targets:     []net.IP{net.IPv4(192, 0, 2, 2)},
+				iface:       &net.Interface{Name: "test0"},
+				logger:      &nullLogger{},
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errc := make(chan error)
+			go func() {
+				errc <- d.Find(ctx, make(chan Entry))
+			}()
+			if tt.close {
+				c.Close()
+			} else {
+				// nobody receives the reply, canceling must not block.
+				<-c.replied
+				cancel()
+			}
+			select {
+			case err := <-errc:
+				if (err != nil) != tt.wantErr {
+					t.Errorf("Find() error = %v, wantErr %v", err, tt.wantErr)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatal("Find() did not return")
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package arp
+
+import (
+	"strconv"
+	"strings"
+)
+
+// NUDState is the state of a neighbor, see NUD_* in linux/neighbour.h.
+type NUDState uint16
+
+// States of a neighbor.
+const (
+	NUDIncomplete NUDState = 0x01
+	NUDReachable  NUDState = 0x02
+	NUDStale      NUDState = 0x04
+	NUDDelay      NUDState = 0x08
+	NUDProbe      NUDState = 0x10
+	NUDFailed     NUDState = 0x20
+	NUDNoARP      NUDState = 0x40
+	NUDPermanent  NUDState = 0x80
+)
+
+// nudValid are the states with a usable hardware address.
+const nudValid = NUDPermanent | NUDNoARP | NUDReachable | NUDProbe | NUDStale | NUDDelay
+
+var nudNames = []struct {
+	state NUDState
+	name  string
+}{
+	{NUDIncomplete, "incomplete"},
+	{NUDReachable, "reachable"},
+	{NUDStale, "stale"},
+	{NUDDelay, "delay"},
+	{NUDProbe, "probe"},
+	{NUDFailed, "failed"},
+	{NUDNoARP, "noarp"},
+	{NUDPermanent, "permanent"},
+}
+
+// String returns the set states separated by comma as printed by
+// "ip neigh", e.g. "reachable". The zero value is "none".
+func (s NUDState) String() string {
+	if s == 0 {
+		return "none"
+	}
+	var names []string
+	for _, n := range nudNames {
+		if s&n.state != 0 {
+			names = append(names, n.name)
+			s &^= n.state
+		}
+	}
+	if s != 0 {
+		names = append(names, "0x"+strconv.FormatUint(uint64(s), 16))
+	}
+	return strings.Join(names, ",")
+}
+
+// flags returns the flags /proc/net/arp shows for the state.
+func (s NUDState) flags() Flags {
+	var f Flags
+	if s&nudValid != 0 {
+		f |= FlagComplete
+	}
+	if s&NUDPermanent != 0 {
+		f |= FlagPermanent
+	}
+	return f
+}
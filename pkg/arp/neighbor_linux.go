@@ -0,0 +1,171 @@
+// +build linux
+
+package arp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// Neighbors returns the IPv4 and IPv6 neighbors of the kernel, as listed by
+// "ip neigh", read by a RTM_GETNEIGH dump over rtnetlink. Entries in state
+// NOARP, e.g. those of multicast addresses, are skipped as they do not
+// belong to devices. The hardware type of the entries is unknown.
+func Neighbors() ([]*Entry, error) {
+	fd, err := unix.Socket(unix.AF_NETLINK, unix.SOCK_RAW|unix.SOCK_CLOEXEC, unix.NETLINK_ROUTE)
+	if err != nil {
+		return nil, os.NewSyscallError("socket", err)
+	}
+	defer unix.Close(fd)
+	sa := &unix.SockaddrNetlink{Family: unix.AF_NETLINK}
+	if err := unix.Bind(fd, sa); err != nil {
+		return nil, os.NewSyscallError("bind", err)
+	}
+
+	const seq = 1
+	req := make([]byte, unix.SizeofNlMsghdr+unix.SizeofNdMsg)
+	*(*unix.NlMsghdr)(unsafe.Pointer(&req[0])) = unix.NlMsghdr{
+		Len:   uint32(len(req)),
+		Type:  unix.RTM_GETNEIGH,
+		Flags: unix.NLM_F_REQUEST | unix.NLM_F_DUMP,
+		Seq:   seq,
+	}
+	*(*unix.NdMsg)(unsafe.Pointer(&req[unix.SizeofNlMsghdr])) = unix.NdMsg{Family: unix.AF_UNSPEC}
+	if err := unix.Sendto(fd, req, 0, sa); err != nil {
+		return nil, os.NewSyscallError("sendto", err)
+	}
+
+	entries := make([]*Entry, 0)
+	buf := make([]byte, 32*1024)
+	for {
+		n, _, err := unix.Recvfrom(fd, buf, 0)
+		if err != nil {
+			return nil, os.NewSyscallError("recvfrom", err)
+		}
+		found, done, err := parseNeighbors(buf[:n], seq, interfaceByIndex)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, found...)
+		if done {
+			return entries, nil
+		}
+	}
+}
+
+// Cache returns the entries of the neighbor table, see Neighbors. If it
+// cannot be read, e.g. because netlink is not permitted, the IPv4 entries of
+// /proc/net/arp are returned.
+func Cache() ([]*Entry, error) {
+	entries, err := Neighbors()
+	if err == nil {
+		return entries, nil
+	}
+	r, cerr := FromCache()
+	if cerr != nil {
+		return nil, fmt.Errorf("neighbors: %v, arp cache: %w", err, cerr)
+	}
+	return ParseEntries(r), nil
+}
+
+func interfaceByIndex(index int) *net.Interface {
+	iface, err := net.InterfaceByIndex(index)
+	if err != nil {
+		return nil
+	}
+	return iface
+}
+
+// parseNeighbors parses the netlink messages of a RTM_GETNEIGH dump, done
+// reports whether the end of the dump has been reached. Messages of other
+// requests are ignored.
+func parseNeighbors(b []byte, seq uint32, iface func(int) *net.Interface) (entries []*Entry, done bool, err error) {
+	for len(b) >= unix.SizeofNlMsghdr {
+		h := (*unix.NlMsghdr)(unsafe.Pointer(&b[0]))
+		if h.Len < unix.SizeofNlMsghdr || int(h.Len) > len(b) {
+			return nil, false, errors.New("netlink: invalid message length")
+		}
+		msg := b[unix.SizeofNlMsghdr:h.Len]
+		b = b[min(nlmAlign(int(h.Len)), len(b)):]
+		if h.Seq != seq {
+			continue
+		}
+		switch h.Type {
+		case unix.NLMSG_DONE:
+			return entries, true, nil
+		case unix.NLMSG_ERROR:
+			if len(msg) < unix.SizeofNlMsgerr {
+				return nil, false, errors.New("netlink: invalid error message")
+			}
+			if errno := (*unix.NlMsgerr)(unsafe.Pointer(&msg[0])).Error; errno != 0 {
+				return nil, false, os.NewSyscallError("netlink", syscall.Errno(-errno))
+			}
+		case unix.RTM_NEWNEIGH:
+			if e := parseNeighbor(msg, iface); e != nil {
+				entries = append(entries, e)
+			}
+		}
+	}
+	return entries, false, nil
+}
+
+// parseNeighbor parses the payload of a RTM_NEWNEIGH message. It returns nil
+// for malformed messages, other families and entries in state NOARP.
+func parseNeighbor(msg []byte, iface func(int) *net.Interface) *Entry {
+	if len(msg) < unix.SizeofNdMsg {
+		return nil
+	}
+	nd := (*unix.NdMsg)(unsafe.Pointer(&msg[0]))
+	if nd.Family != unix.AF_INET && nd.Family != unix.AF_INET6 {
+		return nil
+	}
+	state := NUDState(nd.State)
+	if state&NUDNoARP != 0 {
+		return nil
+	}
+	e := &Entry{
+		Flags:  state.flags(),
+		NUD:    state,
+		Router: nd.Flags&unix.NTF_ROUTER != 0,
+		Device: iface(int(nd.Ifindex)),
+	}
+	for attrs := msg[nlmAlign(unix.SizeofNdMsg):]; len(attrs) >= unix.SizeofRtAttr; {
+		a := (*unix.RtAttr)(unsafe.Pointer(&attrs[0]))
+		if a.Len < unix.SizeofRtAttr || int(a.Len) > len(attrs) {
+			return nil
+		}
+		data := attrs[unix.SizeofRtAttr:a.Len]
+		attrs = attrs[min(rtaAlign(int(a.Len)), len(attrs)):]
+		switch a.Type &^ (unix.NLA_F_NESTED | unix.NLA_F_NET_BYTEORDER) {
+		case unix.NDA_DST:
+			e.Address = append(net.IP(nil), data...)
+		case unix.NDA_LLADDR:
+			e.Mac = append(net.HardwareAddr(nil), data...)
+		}
+	}
+	if e.Address == nil {
+		return nil
+	}
+	return e
+}
+
+func nlmAlign(n int) int {
+	return (n + unix.NLMSG_ALIGNTO - 1) &^ (unix.NLMSG_ALIGNTO - 1)
+}
+
+func rtaAlign(n int) int {
+	return (n + unix.RTA_ALIGNTO - 1) &^ (unix.RTA_ALIGNTO - 1)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
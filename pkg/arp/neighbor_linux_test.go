@@ -0,0 +1,176 @@
+// +build linux
+
+package arp
+
+import (
+	"net"
+	"syscall"
+	"testing"
+	"unsafe"
+
+	"github.com/google/go-cmp/cmp"
+	"golang.org/x/sys/unix"
+)
+
+// nlMessage builds a netlink message in native byte order.
+func nlMessage(typ uint16, seq uint32, payload []byte) []byte {
+	b := make([]byte, nlmAlign(unix.SizeofNlMsghdr+len(payload)))
+	*(*unix.NlMsghdr)(unsafe.Pointer(&b[0])) = unix.NlMsghdr{
+		Len:  uint32(unix.SizeofNlMsghdr + len(payload)),
+		Type: typ,
+		Seq:  seq,
+	}
+	copy(b[unix.SizeofNlMsghdr:], payload)
+	return b
+}
+
+// ndPayload builds the payload of a RTM_NEWNEIGH message, dst and lladdr are
+// omitted if nil.
+func ndPayload(family uint8, index int32, state NUDState, flags uint8, dst, lladdr []byte) []byte {
+	b := make([]byte, unix.SizeofNdMsg)
+	*(*unix.NdMsg)(unsafe.Pointer(&b[0])) = unix.NdMsg{Family: family, Ifindex: index, State: uint16(state), Flags: flags}
+	for _, a := range []struct {
+		typ  uint16
+		data []byte
+	}{{unix.NDA_DST, dst}, {unix.NDA_LLADDR, lladdr}} {
+		if a.data == nil {
+			continue
+		}
+		attr := make([]byte, rtaAlign(unix.SizeofRtAttr+len(a.data)))
+		*(*unix.RtAttr)(unsafe.Pointer(&attr[0])) = unix.RtAttr{Len: uint16(unix.SizeofRtAttr + len(a.data)), Type: a.typ}
+		copy(attr[unix.SizeofRtAttr:], a.data)
+		b = append(b, attr...)
+	}
+	return b
+}
+
+func errMessage(seq uint32, errno syscall.Errno) []byte {
+	b := make([]byte, unix.SizeofNlMsgerr)
+	(*unix.NlMsgerr)(unsafe.Pointer(&b[0])).Error = -int32(errno)
+	return nlMessage(unix.NLMSG_ERROR, seq, b)
+}
+
+func concat(msgs ...[]byte) []byte {
+	var b []byte
+	for _, m := range msgs {
+		b = append(b, m...)
+	}
+	return b
+}
+
+func TestParseNeighbors(t *testing.T) {
+	ifaces := map[int]*net.Interface{
+		2: {Index: 2, Name: "eth0"},
+		3: {Index: 3, Name: "wlan0"},
+	}
+	iface := func(i int) *net.Interface { return ifaces[i] }
+	mac := mustParseMAC(t, "3c:a6:2f:12:34:56")
+	router := mustParseMAC(t, "52:54:00:ab:cd:ef")
+
+	tt := []struct {
+		name     string
+		b        []byte
+		want     []*Entry
+		wantDone bool
+		wantErr  bool
+	}{
+		{
+			name: "dump",
+			b: concat(
+				nlMessage(unix.RTM_NEWNEIGH, 1, ndPayload(unix.AF_INET, 2, NUDReachable, 0, net.ParseIP("192.168.178.1").To4(), mac)),
+				nlMessage(unix.RTM_NEWNEIGH, 1, ndPayload(unix.AF_INET6, 3, NUDStale, unix.NTF_ROUTER, net.ParseIP("fe80::5054:ff:feab:cdef"), router)),
+				nlMessage(unix.RTM_NEWNEIGH, 1, ndPayload(unix.AF_INET6, 3, NUDFailed, 0, net.ParseIP("2001:db8::42"), nil)),
+				nlMessage(unix.RTM_NEWNEIGH, 1, ndPayload(unix.AF_INET, 2, NUDPermanent, 0, net.ParseIP("192.168.178.2").To4(), router)),
+				// multicast entries and other families are skipped.
+				nlMessage(unix.RTM_NEWNEIGH, 1, ndPayload(unix.AF_INET6, 2, NUDNoARP, 0, net.ParseIP("ff02::16"), mustParseMAC(t, "33:33:00:00:00:16"))),
+				nlMessage(unix.RTM_NEWNEIGH, 1, ndPayload(unix.AF_BRIDGE, 2, NUDPermanent, 0, nil, mac)),
+				// messages of other requests are ignored.
+				nlMessage(unix.RTM_NEWNEIGH, 2, ndPayload(unix.AF_INET, 2, NUDReachable, 0, net.ParseIP("10.0.0.1").To4(), mac)),
+				nlMessage(unix.NLMSG_DONE, 1, make([]byte, 4)),
+			),
+			want: []*Entry{
+				{
+					Address: net.ParseIP("192.168.178.1").To4(),
+					Flags:   FlagComplete,
+					Mac:     mac,
+					Device:  ifaces[2],
+					NUD:     NUDReachable,
+				},
+				{
+					Address: net.ParseIP("fe80::5054:ff:feab:cdef"),
+					Flags:   FlagComplete,
+					Mac:     router,
+					Device:  ifaces[3],
+					NUD:     NUDStale,
+					Router:  true,
+				},
+				{
+					Address: net.ParseIP("2001:db8::42"),
+					Device:  ifaces[3],
+					NUD:     NUDFailed,
+				},
+				{
+					Address: net.ParseIP("192.168.178.2").To4(),
+					Flags:   FlagComplete | FlagPermanent,
+					Mac:     router,
+					Device:  ifaces[2],
+					NUD:     NUDPermanent,
+				},
+			},
+			wantDone: true,
+		},
+		{
+			name: "partial",
+			b: concat(
+				nlMessage(unix.RTM_NEWNEIGH, 1, ndPayload(unix.AF_INET, 9, NUDDelay, 0, net.ParseIP("192.168.178.1").To4(), mac)),
+			),
+			want: []*Entry{
+				{
+					Address: net.ParseIP("192.168.178.1").To4(),
+					Flags:   FlagComplete,
+					Mac:     mac,
+					NUD:     NUDDelay,
+				},
+			},
+		},
+		{
+			name:    "error",
+			b:       errMessage(1, syscall.EPERM),
+			wantErr: true,
+		},
+		{
+			name:    "truncated",
+			b:       nlMessage(unix.RTM_NEWNEIGH, 1, ndPayload(unix.AF_INET, 2, NUDReachable, 0, net.ParseIP("192.168.178.1").To4(), mac))[:20],
+			wantErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, done, err := parseNeighbors(tc.b, 1, iface)
+			if (err != nil) != tc.wantErr {
+				t.Fatalf("parseNeighbors() error = %v, wantErr %v", err, tc.wantErr)
+			}
+			if done != tc.wantDone {
+				t.Errorf("parseNeighbors() done = %v, want %v", done, tc.wantDone)
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
+
+func TestNeighbors(t *testing.T) {
+	entries, err := Neighbors()
+	if err != nil {
+		t.Skip("neighbor table not available:", err)
+	}
+	for _, e := range entries {
+		if e.Address == nil {
+			t.Errorf("entry without address: %+v", e)
+		}
+		if e.NUD&NUDNoARP != 0 {
+			t.Errorf("noarp entry not skipped: %+v", e)
+		}
+	}
+}
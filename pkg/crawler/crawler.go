@@ -0,0 +1,329 @@
+// Package crawler downloads vendor tables into a local directory. Every
+// download is validated before it replaces the previous copy, and the state
+// of a run is recorded in a manifest next to the tables. A Mirror serves the
+// directory over http.
+package crawler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+// Logger interface passes to Crawler
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+type nullLogger struct{}
+
+func (*nullLogger) Printf(format string, v ...interface{}) {}
+
+// Option recognized by Crawler
+type Option func(*Crawler)
+
+// WithLogger creates an option that sets the given logger to a Crawler object
+func WithLogger(l Logger) Option {
+	return func(c *Crawler) {
+		c.logger = l
+	}
+}
+
+// WithClient sets the http client used for downloads.
+func WithClient(hc *http.Client) Option {
+	return func(c *Crawler) {
+		c.client = hc
+	}
+}
+
+// WithRetries sets how often a failed download is retried. The delay before
+// the first retry is backoff and doubles with every further attempt.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Crawler) {
+		c.retries = n
+		c.backoff = backoff
+	}
+}
+
+// WithSnapshots keeps a dated copy of every downloaded table in dir, named
+// <date>_<name>, e.g. 2026-10-16_oui.csv. Tables that did not change are not
+// copied again. Two snapshots can be compared with crawler -diff.
+func WithSnapshots(dir string) Option {
+	return func(c *Crawler) {
+		c.snapshots = dir
+	}
+}
+
+// Crawler downloads tables into a directory.
+type Crawler struct {
+	dir       string
+	snapshots string
+	client    *http.Client
+	retries   int
+	backoff   time.Duration
+	logger    Logger
+}
+
+// New creates a Crawler that stores the tables in dir.
+func New(dir string, opts ...Option) *Crawler {
+	c := &Crawler{
+		dir:     dir,
+		client:  &http.Client{Timeout: 30 * time.Second},
+		retries: 3,
+		backoff: time.Second,
+		logger:  &nullLogger{},
+	}
+	for _, o := range opts {
+		o(c)
+	}
+	return c
+}
+
+// Run downloads the given urls. A table is stored under the base name of its
+// url, e.g. oui.csv, and only replaced once the new copy has been parsed
+// successfully. Tables that did not change since the previous run, as told
+// by ETag or Last-Modified, are not downloaded again.
+//
+// The manifest of the run is written even if some downloads failed, for
+// those it keeps the entries of the previous run. Entries of tables that
+// were not requested in this run are kept as long as the table exists.
+func (c *Crawler) Run(ctx context.Context, urls []string) (*Manifest, error) {
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return nil, err
+	}
+	prev, err := ReadManifest(c.dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		c.logger.Printf("ignore previous manifest: %v", err)
+	}
+	m := &Manifest{Time: time.Now().UTC()}
+	var (
+		failed   int
+		firstErr error
+	)
+	for _, u := range urls {
+		old := prev.file(u)
+		f, err := c.fetch(ctx, u, old)
+		if err != nil {
+			c.logger.Printf("%s: %v", u, err)
+			failed++
+			if firstErr == nil {
+				firstErr = fmt.Errorf("%s: %w", u, err)
+			}
+			if old == nil {
+				continue
+			}
+			f = old
+		}
+		m.Files = append(m.Files, *f)
+	}
+	if prev != nil {
+		for _, f := range prev.Files {
+			if m.file(f.URL) != nil {
+				continue
+			}
+			if _, err := os.Stat(filepath.Join(c.dir, f.Name)); err != nil {
+				c.logger.Printf("%s: drop from manifest: %v", f.URL, err)
+				continue
+			}
+			m.Files = append(m.Files, f)
+		}
+	}
+	if err := m.write(c.dir); err != nil {
+		return nil, err
+	}
+	if failed > 0 {
+		return m, fmt.Errorf("%d of %d downloads failed, first: %w", failed, len(urls), firstErr)
+	}
+	return m, nil
+}
+
+// fetch downloads a single table, retrying temporary failures.
+func (c *Crawler) fetch(ctx context.Context, u string, old *File) (*File, error) {
+	name, err := fileName(u)
+	if err != nil {
+		return nil, err
+	}
+	// only revalidate if the table of the previous run still exists.
+	if old != nil {
+		if _, err := os.Stat(filepath.Join(c.dir, old.Name)); err != nil || old.Name != name {
+			old = nil
+		}
+	}
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		f, err := c.try(ctx, u, name, old)
+		if err == nil || attempt >= c.retries || !temporary(err) {
+			return f, err
+		}
+		c.logger.Printf("%s: %v, retry in %s", u, err, delay)
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// statusError is returned for unexpected http responses.
+type statusError struct {
+	code int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status: %d %s", e.code, http.StatusText(e.code))
+}
+
+// temporary reports whether a download should be retried. Network errors,
+// server errors and rate limits are retried, everything else is final.
+func temporary(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		return se.code >= 500 || se.code == http.StatusTooManyRequests
+	}
+	var ie *invalidError
+	return !errors.As(err, &ie) && !errors.Is(err, context.Canceled)
+}
+
+// invalidError is returned if a downloaded table can not be parsed.
+type invalidError struct {
+	err error
+}
+
+func (e *invalidError) Error() string {
+	return fmt.Sprintf("invalid table: %v", e.err)
+}
+
+func (e *invalidError) Unwrap() error {
+	return e.err
+}
+
+func (c *Crawler) try(ctx context.Context, u, name string, old *File) (*File, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	if old != nil {
+		if old.ETag != "" {
+			req.Header.Set("If-None-Match", old.ETag)
+		}
+		if old.LastModified != "" {
+			req.Header.Set("If-Modified-Since", old.LastModified)
+		}
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && old != nil:
+		c.logger.Printf("%s: not modified", u)
+		f := *old
+		return &f, nil
+	case resp.StatusCode != http.StatusOK:
+		return nil, &statusError{code: resp.StatusCode}
+	}
+
+	tmp, err := os.CreateTemp(c.dir, "."+name+".*.tmp")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(tmp, h), resp.Body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.ContentLength >= 0 && size != resp.ContentLength {
+		return nil, fmt.Errorf("truncated download: got %d of %d bytes", size, resp.ContentLength)
+	}
+	mp, err := macpack.New(macpack.WithLocalSource(tmp.Name()))
+	if err != nil {
+		return nil, &invalidError{err: err}
+	}
+	if mp.Len() == 0 {
+		return nil, &invalidError{err: errors.New("no assignments")}
+	}
+	// CreateTemp restricts the file to the owner, tables are public.
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return nil, err
+	}
+	if err := os.Rename(tmp.Name(), filepath.Join(c.dir, name)); err != nil {
+		return nil, err
+	}
+	f := &File{
+		URL:          u,
+		Name:         name,
+		Time:         time.Now().UTC(),
+		SHA256:       hex.EncodeToString(h.Sum(nil)),
+		Size:         size,
+		Records:      mp.Len(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	c.logger.Printf("%s: stored %s, %d records", u, name, f.Records)
+	if c.snapshots != "" {
+		// the table is stored, a missing snapshot is not worth a retry.
+		if err := c.snapshot(name, f.Time); err != nil {
+			c.logger.Printf("%s: snapshot: %v", u, err)
+		}
+	}
+	return f, nil
+}
+
+// snapshot copies the stored table name into the snapshot directory.
+func (c *Crawler) snapshot(name string, t time.Time) error {
+	if err := os.MkdirAll(c.snapshots, 0755); err != nil {
+		return err
+	}
+	src, err := os.Open(filepath.Join(c.dir, name))
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst := filepath.Join(c.snapshots, t.Format("2006-01-02")+"_"+name)
+	tmp, err := os.CreateTemp(c.snapshots, "."+filepath.Base(dst)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, src)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+// fileName returns the name a table is stored under, the base of its url.
+func fileName(u string) (string, error) {
+	p, err := url.Parse(u)
+	if err != nil {
+		return "", err
+	}
+	name := path.Base(p.Path)
+	if name == "." || name == "/" || name == ManifestName {
+		return "", fmt.Errorf("%s: url does not name a file", u)
+	}
+	return name, nil
+}
@@ -0,0 +1,219 @@
+package crawler
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testTable = `Registry,Assignment,Organization Name,Organization Address
+MA-L,002272,American Micro-Fuel Device Corp.,2181 Buchanan Loop Ferndale WA US 98248
+MA-L,00D0EF,IGT,9295 PROTOTYPE DRIVE RENO NV US 89511
+`
+
+// testServer serves body at every path. The first failures requests are
+// answered with a 503.
+type testServer struct {
+	mu       sync.Mutex
+	body     string
+	etag     string
+	failures int
+	requests []string
+}
+
+func (s *testServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failures > 0 {
+		s.failures--
+		s.requests = append(s.requests, "503")
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	if s.etag != "" && r.Header.Get("If-None-Match") == s.etag {
+		s.requests = append(s.requests, "304")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	s.requests = append(s.requests, "200")
+	w.Header().Set("ETag", s.etag)
+	w.Write([]byte(s.body))
+}
+
+func testCrawler(t *testing.T, dir string) *Crawler {
+	return New(dir, WithRetries(2, time.Millisecond))
+}
+
+func TestCrawler_Run(t *testing.T) {
+	ts := &testServer{body: testTable, etag: `"v1"`, failures: 1}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+	dir := t.TempDir()
+	u := srv.URL + "/oui/oui.csv"
+
+	m, err := testCrawler(t, dir).Run(context.Background(), []string{u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := File{
+		URL:     u,
+		Name:    "oui.csv",
+		SHA256:  fmt.Sprintf("%x", sha256.Sum256([]byte(testTable))),
+		Size:    int64(len(testTable)),
+		Records: 2,
+		ETag:    `"v1"`,
+	}
+	if len(m.Files) != 1 {
+		t.Fatalf("got %d files, want 1", len(m.Files))
+	}
+	got := m.Files[0]
+	got.Time = time.Time{}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "oui.csv"))
+	if err != nil || string(b) != testTable {
+		t.Errorf("stored table: %q, %v", b, err)
+	}
+
+	// the second run revalidates the table.
+	m2, err := testCrawler(t, dir).Run(context.Background(), []string{u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(m2.Files, m.Files) {
+		t.Error(cmp.Diff(m2.Files, m.Files))
+	}
+	if want := []string{"503", "200", "304"}; !cmp.Equal(ts.requests, want) {
+		t.Errorf("requests: %v, want %v", ts.requests, want)
+	}
+	read, err := ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(read.Files, m.Files) {
+		t.Error(cmp.Diff(read.Files, m.Files))
+	}
+}
+
+func TestCrawler_RunKeepsValidTable(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		failures int
+	}{
+		{name: "invalid table", body: "<html>Service Unavailable</html>"},
+		{name: "empty table", body: "Registry,Assignment,Organization Name,Organization Address\n"},
+		{name: "server errors", body: testTable, failures: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := &testServer{body: testTable}
+			srv := httptest.NewServer(ts)
+			defer srv.Close()
+			dir := t.TempDir()
+			u := srv.URL + "/oui/oui.csv"
+			first, err := testCrawler(t, dir).Run(context.Background(), []string{u})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ts.body, ts.failures = tt.body, tt.failures
+			m, err := testCrawler(t, dir).Run(context.Background(), []string{u})
+			if err == nil {
+				t.Fatal("expected error")
+			}
+			if !cmp.Equal(m.Files, first.Files) {
+				t.Error(cmp.Diff(m.Files, first.Files))
+			}
+			b, err := os.ReadFile(filepath.Join(dir, "oui.csv"))
+			if err != nil || string(b) != testTable {
+				t.Errorf("stored table: %q, %v", b, err)
+			}
+			entries, err := os.ReadDir(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, e := range entries {
+				if strings.HasSuffix(e.Name(), ".tmp") {
+					t.Errorf("temporary file %s left behind", e.Name())
+				}
+			}
+		})
+	}
+}
+
+func TestCrawler_RunKeepsOtherTables(t *testing.T) {
+	srv := httptest.NewServer(&testServer{body: testTable})
+	defer srv.Close()
+	dir := t.TempDir()
+	large, small := srv.URL+"/oui/oui.csv", srv.URL+"/oui36/oui36.csv"
+
+	if _, err := testCrawler(t, dir).Run(context.Background(), []string{large}); err != nil {
+		t.Fatal(err)
+	}
+	m, err := testCrawler(t, dir).Run(context.Background(), []string{small})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.file(large) == nil || m.file(small) == nil {
+		t.Errorf("manifest misses a table: %+v", m.Files)
+	}
+
+	// tables removed from disk are dropped.
+	if err := os.Remove(filepath.Join(dir, "oui.csv")); err != nil {
+		t.Fatal(err)
+	}
+	m, err = testCrawler(t, dir).Run(context.Background(), []string{small})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.file(large) != nil {
+		t.Errorf("manifest keeps removed table: %+v", m.Files)
+	}
+}
+
+func TestCrawler_RunSnapshots(t *testing.T) {
+	ts := &testServer{body: testTable, etag: `"v1"`}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+	dir := t.TempDir()
+	snapshots := filepath.Join(dir, "snapshots")
+	u := srv.URL + "/oui/oui.csv"
+
+	c := New(dir, WithRetries(2, time.Millisecond), WithSnapshots(snapshots))
+	m, err := c.Run(context.Background(), []string{u})
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := m.Files[0].Time.Format("2006-01-02") + "_oui.csv"
+	b, err := os.ReadFile(filepath.Join(snapshots, name))
+	if err != nil || string(b) != testTable {
+		t.Errorf("snapshot: %q, %v", b, err)
+	}
+
+	// an unchanged table is not copied again.
+	if err := os.Remove(filepath.Join(snapshots, name)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Run(context.Background(), []string{u}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(snapshots)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("unexpected snapshots: %v", entries)
+	}
+}
@@ -0,0 +1,84 @@
+package crawler
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ManifestName is the file name of the manifest inside the crawler
+// directory.
+const ManifestName = "manifest.json"
+
+// Manifest describes the tables of a crawler directory.
+type Manifest struct {
+	// Time of the run that wrote the manifest.
+	Time  time.Time `json:"time"`
+	Files []File    `json:"files"`
+}
+
+// File is a downloaded table.
+type File struct {
+	URL string `json:"url"`
+	// Name of the file inside the crawler directory.
+	Name string `json:"name"`
+	// Time the content was downloaded.
+	Time    time.Time `json:"time"`
+	SHA256  string    `json:"sha256"`
+	Size    int64     `json:"size"`
+	Records int       `json:"records"`
+	// ETag and LastModified are the validators sent by the server.
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// ReadManifest reads the manifest of a crawler directory.
+func ReadManifest(dir string) (*Manifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ManifestName))
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+func (m *Manifest) file(url string) *File {
+	if m == nil {
+		return nil
+	}
+	for i := range m.Files {
+		if m.Files[i].URL == url {
+			f := m.Files[i]
+			return &f
+		}
+	}
+	return nil
+}
+
+// write stores the manifest atomically in dir.
+func (m *Manifest) write(dir string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+ManifestName+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(append(b, '\n'))
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, ManifestName))
+}
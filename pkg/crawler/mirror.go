@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Mirror serves the tables of a crawler directory over http. Each table is
+// served at the path of the url it was downloaded from, e.g. /oui/oui.csv,
+// so clients only need to replace the host of the original url. The
+// manifest is served at /manifest.json.
+type Mirror struct {
+	dir string
+
+	mu       sync.RWMutex
+	manifest *Manifest
+	raw      []byte
+	files    map[string]File
+}
+
+// NewMirror creates a Mirror for the tables in dir.
+func NewMirror(dir string) (*Mirror, error) {
+	m := &Mirror{dir: dir}
+	if err := m.Reload(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload reads the manifest again, it has to be called after a crawler
+// run to serve the new tables.
+func (m *Mirror) Reload() error {
+	manifest, err := ReadManifest(m.dir)
+	if err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	files := make(map[string]File, len(manifest.Files))
+	for _, f := range manifest.Files {
+		u, err := url.Parse(f.URL)
+		if err != nil {
+			return err
+		}
+		files[u.Path] = f
+	}
+	m.mu.Lock()
+	m.manifest, m.raw, m.files = manifest, raw, files
+	m.mu.Unlock()
+	return nil
+}
+
+// ServeHTTP implements http.Handler. Responses carry Last-Modified and ETag
+// headers, so conditional requests are answered with 304 Not Modified.
+func (m *Mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+	m.mu.RLock()
+	manifest, raw := m.manifest, m.raw
+	f, ok := m.files[r.URL.Path]
+	m.mu.RUnlock()
+
+	if r.URL.Path == "/"+ManifestName {
+		w.Header().Set("Content-Type", "application/json")
+		http.ServeContent(w, r, ManifestName, manifest.Time, bytes.NewReader(raw))
+		return
+	}
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	file, err := os.Open(filepath.Join(m.dir, f.Name))
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	w.Header().Set("ETag", `"`+f.SHA256+`"`)
+	http.ServeContent(w, r, f.Name, f.Time, file)
+}
@@ -0,0 +1,90 @@
+package crawler
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+func TestMirror(t *testing.T) {
+	src := httptest.NewServer(&testServer{body: testTable})
+	defer src.Close()
+	dir := t.TempDir()
+	m, err := testCrawler(t, dir).Run(context.Background(), []string{src.URL + "/oui/oui.csv"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mirror, err := NewMirror(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(mirror)
+	defer srv.Close()
+
+	etag := `"` + m.Files[0].SHA256 + `"`
+	lastModified := m.Files[0].Time.Format(http.TimeFormat)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		header map[string]string
+		status int
+		body   string
+	}{
+		{name: "table", path: "/oui/oui.csv", status: http.StatusOK, body: testTable},
+		{name: "head", method: http.MethodHead, path: "/oui/oui.csv", status: http.StatusOK},
+		{name: "etag", path: "/oui/oui.csv", header: map[string]string{"If-None-Match": etag}, status: http.StatusNotModified},
+		{name: "changed etag", path: "/oui/oui.csv", header: map[string]string{"If-None-Match": `"old"`}, status: http.StatusOK, body: testTable},
+		{name: "last modified", path: "/oui/oui.csv", header: map[string]string{"If-Modified-Since": lastModified}, status: http.StatusNotModified},
+		{name: "manifest", path: "/manifest.json", status: http.StatusOK},
+		{name: "unknown", path: "/oui28/mam.csv", status: http.StatusNotFound},
+		{name: "method", method: http.MethodPost, path: "/oui/oui.csv", status: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, err := http.NewRequest(method, srv.URL+tt.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			body, _ := io.ReadAll(resp.Body)
+			if resp.StatusCode != tt.status {
+				t.Fatalf("status %d, want %d", resp.StatusCode, tt.status)
+			}
+			if tt.body != "" && string(body) != tt.body {
+				t.Errorf("body %q, want %q", body, tt.body)
+			}
+			if tt.path == "/oui/oui.csv" && resp.StatusCode == http.StatusOK {
+				if got := resp.Header.Get("ETag"); got != etag {
+					t.Errorf("etag %q, want %q", got, etag)
+				}
+				if got := resp.Header.Get("Last-Modified"); got != lastModified {
+					t.Errorf("last modified %q, want %q", got, lastModified)
+				}
+			}
+		})
+	}
+
+	mp, err := macpack.New(macpack.WithRemoteSource(srv.URL + "/oui/oui.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mp.Len() != 2 {
+		t.Errorf("got %d assignments from the mirror, want 2", mp.Len())
+	}
+}
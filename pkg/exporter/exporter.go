@@ -0,0 +1,175 @@
+// Package exporter exposes the devices found by "vlookup exporter" as
+// Prometheus metrics in the text exposition format:
+//
+//	vlookup_interface_devices{interface}  devices per network interface
+//	vlookup_vendor_devices{vendor}        devices per vendor
+//	vlookup_class_devices{class}          devices per address classification
+//	vlookup_state_devices{state}          devices per state of the arp entry
+//	vlookup_unresolved_devices            devices without a known vendor
+//	vlookup_scan_duration_seconds         duration of the most recent scan
+//	vlookup_scan_replies                  arp replies received by the scan
+//	vlookup_scan_timestamp_seconds        time of the most recent scan
+//	vlookup_scans_total                   number of finished scans
+//	vlookup_database_age_seconds          age of the vendor database
+//	vlookup_database_assignments          assignments of the vendor database
+//	vlookup_database_reloads_total        successful reloads of the database
+//	vlookup_database_reload_failed        1 if the last reload failed
+//
+// Devices of an unknown vendor or interface are counted as "unknown".
+// Incomplete entries have no hardware address yet, they are only counted per
+// interface and state. The scan metrics are omitted until the first scan
+// finished.
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/frzifus/vlookup/pkg/api"
+	"github.com/frzifus/vlookup/pkg/arp"
+	"github.com/frzifus/vlookup/pkg/macpack"
+)
+
+// ContentType of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Scan is the result of a scan, Devices are the rows vlookup prints in its
+// table.
+type Scan struct {
+	Time     time.Time
+	Duration time.Duration
+	// Replies counts the arp replies received by the active scan.
+	Replies int
+	Devices []api.Device
+}
+
+// Exporter serves the metrics of the most recent scan and the database.
+type Exporter struct {
+	db  *macpack.DB
+	now func() time.Time
+
+	mu    sync.RWMutex
+	scan  Scan
+	scans int
+}
+
+// New creates an Exporter for the database.
+func New(db *macpack.DB) *Exporter {
+	return &Exporter{db: db, now: time.Now}
+}
+
+// Update replaces the scan the metrics are built from.
+func (e *Exporter) Update(s Scan) {
+	e.mu.Lock()
+	e.scan = s
+	e.scans++
+	e.mu.Unlock()
+}
+
+// ServeHTTP implements http.Handler.
+func (e *Exporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var b bytes.Buffer
+	if err := e.Write(&b); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ContentType)
+	b.WriteTo(w)
+}
+
+// Write writes the metrics in the text exposition format to w.
+func (e *Exporter) Write(w io.Writer) error {
+	e.mu.RLock()
+	s, scans := e.scan, e.scans
+	e.mu.RUnlock()
+
+	ifaces := make(map[string]int)
+	vendors := make(map[string]int)
+	classes := make(map[string]int)
+	states := make(map[string]int)
+	var unresolved int
+	for _, d := range s.Devices {
+		ifaces[orUnknown(d.Interface)]++
+		states[orUnknown(d.State)]++
+		if d.State == string(arp.StateIncomplete) {
+			continue
+		}
+		vendors[orUnknown(d.Name)]++
+		classes[d.Class]++
+		if d.Name == "" {
+			unresolved++
+		}
+	}
+
+	var b bytes.Buffer
+	writeVec(&b, "vlookup_interface_devices", "Number of devices per network interface.", "interface", ifaces)
+	writeVec(&b, "vlookup_vendor_devices", "Number of devices per vendor.", "vendor", vendors)
+	writeVec(&b, "vlookup_class_devices", "Number of devices per address classification.", "class", classes)
+	writeVec(&b, "vlookup_state_devices", "Number of devices per state of the arp entry.", "state", states)
+	writeMetric(&b, "vlookup_unresolved_devices", "gauge", "Number of devices without a known vendor.", float64(unresolved))
+	if !s.Time.IsZero() {
+		writeMetric(&b, "vlookup_scan_duration_seconds", "gauge", "Duration of the most recent scan.", s.Duration.Seconds())
+		writeMetric(&b, "vlookup_scan_replies", "gauge", "Number of arp replies received by the most recent scan.", float64(s.Replies))
+		writeMetric(&b, "vlookup_scan_timestamp_seconds", "gauge", "Unix time of the most recent scan.", unixSeconds(s.Time))
+	}
+	writeMetric(&b, "vlookup_scans_total", "counter", "Number of finished scans.", float64(scans))
+
+	st := e.db.Status()
+	var failed float64
+	if st.Err != nil {
+		failed = 1
+	}
+	writeMetric(&b, "vlookup_database_age_seconds", "gauge", "Time since the vendor database was loaded.", e.now().Sub(st.Loaded).Seconds())
+	writeMetric(&b, "vlookup_database_assignments", "gauge", "Number of assignments of the vendor database.", float64(st.Len))
+	writeMetric(&b, "vlookup_database_reloads_total", "counter", "Number of successful reloads of the vendor database.", float64(st.Reloads))
+	writeMetric(&b, "vlookup_database_reload_failed", "gauge", "Whether the last reload of the vendor database failed.", failed)
+	_, err := b.WriteTo(w)
+	return err
+}
+
+func writeMetric(b *bytes.Buffer, name, typ, help string, v float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n%s %s\n", name, help, name, typ, name, formatValue(v))
+}
+
+// writeVec writes a gauge with one label, the samples are sorted by the
+// label value.
+func writeVec(b *bytes.Buffer, name, help, label string, values map[string]int) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s{%s=\"%s\"} %d\n", name, label, labelEscaper.Replace(k), values[k])
+	}
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatValue(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func unixSeconds(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
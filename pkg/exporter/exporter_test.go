@@ -0,0 +1,124 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/frzifus/vlookup/pkg/api"
+	"github.com/frzifus/vlookup/pkg/macpack"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExporter(t *testing.T) {
+	db, err := macpack.NewDB(macpack.WithReaderSource(strings.NewReader(
+		`Registry,Assignment,Organization Name,Organization Address
+MA-L,00D0EF,IGT,9295 PROTOTYPE DRIVE RENO NV US 89511
+MA-L,F4BD9E,"Cisco Systems, Inc",80 West Tasman Drive San Jose CA US 94568`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	e := New(db)
+	e.now = func() time.Time { return db.Status().Loaded.Add(90 * time.Second) }
+
+	before := `# HELP vlookup_interface_devices Number of devices per network interface.
+# TYPE vlookup_interface_devices gauge
+# HELP vlookup_vendor_devices Number of devices per vendor.
+# TYPE vlookup_vendor_devices gauge
+# HELP vlookup_class_devices Number of devices per address classification.
+# TYPE vlookup_class_devices gauge
+# HELP vlookup_state_devices Number of devices per state of the arp entry.
+# TYPE vlookup_state_devices gauge
+# HELP vlookup_unresolved_devices Number of devices without a known vendor.
+# TYPE vlookup_unresolved_devices gauge
+vlookup_unresolved_devices 0
+# HELP vlookup_scans_total Number of finished scans.
+# TYPE vlookup_scans_total counter
+vlookup_scans_total 0
+`
+	database := `# HELP vlookup_database_age_seconds Time since the vendor database was loaded.
+# TYPE vlookup_database_age_seconds gauge
+vlookup_database_age_seconds 90
+# HELP vlookup_database_assignments Number of assignments of the vendor database.
+# TYPE vlookup_database_assignments gauge
+vlookup_database_assignments 2
+# HELP vlookup_database_reloads_total Number of successful reloads of the vendor database.
+# TYPE vlookup_database_reloads_total counter
+vlookup_database_reloads_total 0
+# HELP vlookup_database_reload_failed Whether the last reload of the vendor database failed.
+# TYPE vlookup_database_reload_failed gauge
+vlookup_database_reload_failed 0
+`
+	if got := scrape(t, e); got != before+database {
+		t.Error(cmp.Diff(got, before+database))
+	}
+
+	e.Update(Scan{
+		Time:     time.Unix(1760616000, 500000000),
+		Duration: 10250 * time.Millisecond,
+		Replies:  3,
+		Devices: []api.Device{
+			{Interface: "eth0", MAC: "f4:bd:9e:00:00:01", State: "static", Class: "unicast", Name: "Cisco Systems, Inc"},
+			{Interface: "eth0", MAC: "f4:bd:9e:00:00:02", State: "dynamic", Class: "unicast", Name: "Cisco Systems, Inc"},
+			{Interface: "eth0", MAC: "00:00:00:00:00:00", State: "incomplete"},
+			{Interface: "wlan0", MAC: "00:d0:ef:00:00:01", State: "dynamic", Class: "unicast", Name: "IGT"},
+			{Interface: "wlan0", MAC: "02:00:00:00:00:01", State: "dynamic", Class: "unicast,aai,random"},
+			{MAC: "00:00:00:00:00:01", Class: "unicast", Name: `Quote " and \ Inc`},
+		},
+	})
+	after := `# HELP vlookup_interface_devices Number of devices per network interface.
+# TYPE vlookup_interface_devices gauge
+vlookup_interface_devices{interface="eth0"} 3
+vlookup_interface_devices{interface="unknown"} 1
+vlookup_interface_devices{interface="wlan0"} 2
+# HELP vlookup_vendor_devices Number of devices per vendor.
+# TYPE vlookup_vendor_devices gauge
+vlookup_vendor_devices{vendor="Cisco Systems, Inc"} 2
+vlookup_vendor_devices{vendor="IGT"} 1
+vlookup_vendor_devices{vendor="Quote \" and \\ Inc"} 1
+vlookup_vendor_devices{vendor="unknown"} 1
+# HELP vlookup_class_devices Number of devices per address classification.
+# TYPE vlookup_class_devices gauge
+vlookup_class_devices{class="unicast"} 4
+vlookup_class_devices{class="unicast,aai,random"} 1
+# HELP vlookup_state_devices Number of devices per state of the arp entry.
+# TYPE vlookup_state_devices gauge
+vlookup_state_devices{state="dynamic"} 3
+vlookup_state_devices{state="incomplete"} 1
+vlookup_state_devices{state="static"} 1
+vlookup_state_devices{state="unknown"} 1
+# HELP vlookup_unresolved_devices Number of devices without a known vendor.
+# TYPE vlookup_unresolved_devices gauge
+vlookup_unresolved_devices 1
+# HELP vlookup_scan_duration_seconds Duration of the most recent scan.
+# TYPE vlookup_scan_duration_seconds gauge
+vlookup_scan_duration_seconds 10.25
+# HELP vlookup_scan_replies Number of arp replies received by the most recent scan.
+# TYPE vlookup_scan_replies gauge
+vlookup_scan_replies 3
+# HELP vlookup_scan_timestamp_seconds Unix time of the most recent scan.
+# TYPE vlookup_scan_timestamp_seconds gauge
+vlookup_scan_timestamp_seconds 1.7606160005e+09
+# HELP vlookup_scans_total Number of finished scans.
+# TYPE vlookup_scans_total counter
+vlookup_scans_total 1
+`
+	if got := scrape(t, e); got != after+database {
+		t.Error(cmp.Diff(got, after+database))
+	}
+}
+
+func scrape(t *testing.T, e *Exporter) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	if got := rec.Header().Get("Content-Type"); got != ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, ContentType)
+	}
+	return rec.Body.String()
+}
@@ -0,0 +1,102 @@
+package macaddr
+
+import (
+	"bytes"
+	"net"
+	"strings"
+)
+
+const (
+	bitGroup = 0x01 // I/G bit, set for multicast
+	bitLocal = 0x02 // U/L bit, set for locally administered addresses
+)
+
+var broadcast = net.HardwareAddr{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+// Quadrant is the structured local address plan (SLAP) quadrant of a locally
+// administered address as defined by IEEE 802c. It is derived from the Y and
+// Z bits of the first octet.
+type Quadrant int
+
+// SLAP quadrants, QuadrantNone is used for universally administered
+// addresses.
+const (
+	QuadrantNone     Quadrant = iota
+	QuadrantAAI               // administratively assigned identifier, x2-xx-xx
+	QuadrantReserved          // reserved for future use, x6-xx-xx
+	QuadrantELI               // extended local identifier, xA-xx-xx
+	QuadrantSAI               // standard assigned identifier, xE-xx-xx
+)
+
+func (q Quadrant) String() string {
+	switch q {
+	case QuadrantAAI:
+		return "aai"
+	case QuadrantReserved:
+		return "reserved"
+	case QuadrantELI:
+		return "eli"
+	case QuadrantSAI:
+		return "sai"
+	}
+	return "none"
+}
+
+// Class describes the kind of a hardware address.
+type Class struct {
+	// Multicast is set if the individual/group bit is set, this includes
+	// the broadcast address.
+	Multicast bool
+	// Broadcast is set for ff:ff:ff:ff:ff:ff.
+	Broadcast bool
+	// Local is set if the address is locally administered and therefore
+	// not assigned by the IEEE.
+	Local bool
+	// Quadrant is the SLAP quadrant of locally administered addresses.
+	Quadrant Quadrant
+	// Randomized is set for locally administered unicast addresses.
+	// Phones and most operating systems use them as privacy addresses and
+	// only set the U/L bit, so every quadrant can be hit. Addresses of the
+	// ELI and SAI quadrants might be assigned deliberately, which can only be
+	// told by a registry lookup, hence the address is likely randomized.
+	Randomized bool
+}
+
+// Classify returns the class of the hardware address. Only the first octet
+// and for broadcast the whole address are taken into account.
+func Classify(mac net.HardwareAddr) Class {
+	if len(mac) == 0 {
+		return Class{}
+	}
+	c := Class{
+		Multicast: mac[0]&bitGroup != 0,
+		Broadcast: bytes.Equal(mac, broadcast),
+		Local:     mac[0]&bitLocal != 0,
+	}
+	if c.Local {
+		c.Quadrant = Quadrant(mac[0]>>2&0x03) + QuadrantAAI
+		c.Randomized = !c.Multicast
+	}
+	return c
+}
+
+// String returns a short description of the class, e.g. "unicast",
+// "multicast,sai" or "unicast,aai,random".
+func (c Class) String() string {
+	var parts []string
+	switch {
+	case c.Broadcast:
+		return "broadcast"
+	case c.Multicast:
+		parts = append(parts, "multicast")
+	default:
+		parts = append(parts, "unicast")
+	}
+	if c.Local {
+		parts = append(parts, c.Quadrant.String())
+	}
+	if c.Randomized {
+		parts = append(parts, "random")
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,72 @@
+package macaddr
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestClassify(t *testing.T) {
+	tt := []struct {
+		mac     string
+		want    Class
+		wantStr string
+	}{
+		{
+			mac:     "f4:bd:9e:00:11:22",
+			want:    Class{},
+			wantStr: "unicast",
+		},
+		{
+			mac:     "ff:ff:ff:ff:ff:ff",
+			want:    Class{Multicast: true, Broadcast: true, Local: true, Quadrant: QuadrantSAI},
+			wantStr: "broadcast",
+		},
+		{
+			mac:     "01:00:5e:00:00:fb",
+			want:    Class{Multicast: true},
+			wantStr: "multicast",
+		},
+		{
+			mac:     "33:33:00:00:00:01",
+			want:    Class{Multicast: true, Local: true, Quadrant: QuadrantAAI},
+			wantStr: "multicast,aai",
+		},
+		{
+			mac:     "02:42:ac:11:00:02",
+			want:    Class{Local: true, Quadrant: QuadrantAAI, Randomized: true},
+			wantStr: "unicast,aai,random",
+		},
+		{
+			mac:     "a6:83:e7:12:34:56",
+			want:    Class{Local: true, Quadrant: QuadrantReserved, Randomized: true},
+			wantStr: "unicast,reserved,random",
+		},
+		{
+			mac:     "da:a1:19:00:00:01",
+			want:    Class{Local: true, Quadrant: QuadrantELI, Randomized: true},
+			wantStr: "unicast,eli,random",
+		},
+		{
+			mac:     "5e:00:00:00:00:01",
+			want:    Class{Local: true, Quadrant: QuadrantSAI, Randomized: true},
+			wantStr: "unicast,sai,random",
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.mac, func(t *testing.T) {
+			mac, err := net.ParseMAC(tc.mac)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := Classify(mac)
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+			if got.String() != tc.wantStr {
+				t.Errorf("String() = %q, want %q", got.String(), tc.wantStr)
+			}
+		})
+	}
+}
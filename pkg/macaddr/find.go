@@ -0,0 +1,125 @@
+package macaddr
+
+import (
+	"regexp"
+)
+
+// Notation of a hardware address in text.
+type Notation int
+
+// Notations recognized by FindAll, they can be combined.
+const (
+	NotationColon Notation = 1 << iota // aa:bb:cc:dd:ee:ff
+	NotationDash                       // aa-bb-cc-dd-ee-ff
+	NotationDot                        // aabb.ccdd.eeff, used by Cisco
+	NotationBare                       // aabbccddeeff
+
+	// NotationCommon contains the notations with separators. Bare hex
+	// digits are excluded, because they are easily confused with other
+	// numbers in text.
+	NotationCommon = NotationColon | NotationDash | NotationDot
+)
+
+var addrPattern = regexp.MustCompile(
+	`(?:[0-9A-Fa-f]{2}:){5}[0-9A-Fa-f]{2}` +
+		`|(?:[0-9A-Fa-f]{2}-){5}[0-9A-Fa-f]{2}` +
+		`|(?:[0-9A-Fa-f]{4}\.){2}[0-9A-Fa-f]{4}` +
+		`|[0-9A-Fa-f]{12}`)
+
+// FindAll returns the positions of all hardware addresses in s that are
+// written in one of the given notations. Each position is a pair of start
+// and end offsets as returned by regexp.FindAllStringIndex. Matches that
+// are part of a longer hex sequence, e.g. an IPv6 address or a hash, are
+// ignored. Hex letters at the end of a word are no part of an address, e.g.
+// "MAC:aa:bb:cc:dd:ee:ff" contains "aa:bb:cc:dd:ee:ff".
+func FindAll(s string, n Notation) [][]int {
+	var found [][]int
+	for i := 0; i < len(s); {
+		m := addrPattern.FindStringIndex(s[i:])
+		if m == nil {
+			break
+		}
+		start, end := i+m[0], i+m[1]
+		if !isolated(s, start, end) {
+			// the match may have started too early, retry at the next group.
+			i = nextGroup(s, start)
+			continue
+		}
+		if notation(s[start:end])&n != 0 {
+			found = append(found, []int{start, end})
+		}
+		i = end
+	}
+	return found
+}
+
+// nextGroup returns the offset after the hex digits at i and the character
+// that follows them.
+func nextGroup(s string, i int) int {
+	for i < len(s) && isHex(s[i]) {
+		i++
+	}
+	return i + 1
+}
+
+func notation(addr string) Notation {
+	switch addr[2] {
+	case ':':
+		return NotationColon
+	case '-':
+		return NotationDash
+	}
+	if addr[4] == '.' {
+		return NotationDot
+	}
+	return NotationBare
+}
+
+// isolated reports whether the match s[start:end] is not surrounded by hex
+// digits, either directly or by a group of hex digits behind a separator.
+func isolated(s string, start, end int) bool {
+	if start > 0 {
+		if isHex(s[start-1]) || isSep(s[start-1]) && groupBefore(s, start-1) {
+			return false
+		}
+	}
+	if end < len(s) {
+		if isHex(s[end]) || isSep(s[end]) && groupAfter(s, end+1) {
+			return false
+		}
+	}
+	return true
+}
+
+// groupBefore reports whether s[:i] ends with hex digits that are not the
+// end of a word.
+func groupBefore(s string, i int) bool {
+	j := i
+	for j > 0 && isHex(s[j-1]) {
+		j--
+	}
+	return j < i && (j == 0 || !isAlnum(s[j-1]))
+}
+
+// groupAfter reports whether s[i:] starts with hex digits that are not the
+// start of a word.
+func groupAfter(s string, i int) bool {
+	j := i
+	for j < len(s) && isHex(s[j]) {
+		j++
+	}
+	return j > i && (j == len(s) || !isAlnum(s[j]))
+}
+
+func isAlnum(c byte) bool {
+	return c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isHex(c byte) bool {
+	_, ok := hexDigit(c)
+	return ok
+}
+
+func isSep(c byte) bool {
+	return c == ':' || c == '-' || c == '.'
+}
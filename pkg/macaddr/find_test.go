@@ -0,0 +1,80 @@
+package macaddr
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFindAll(t *testing.T) {
+	tt := []struct {
+		name string
+		s    string
+		n    Notation
+		want []string
+	}{
+		{
+			name: "tcpdump",
+			s:    "18:49:40.123 f4:bd:9e:00:11:22 > ff:ff:ff:ff:ff:ff, ethertype ARP (0x0806)",
+			n:    NotationCommon,
+			want: []string{"f4:bd:9e:00:11:22", "ff:ff:ff:ff:ff:ff"},
+		},
+		{
+			name: "cisco mac address-table",
+			s:    "  10    f4bd.9e00.1122    DYNAMIC     Gi1/0/1",
+			n:    NotationCommon,
+			want: []string{"f4bd.9e00.1122"},
+		},
+		{
+			name: "dhcp log",
+			s:    "DHCPACK on 192.168.1.20 to F4-BD-9E-00-11-22 (printer) via eth0",
+			n:    NotationCommon,
+			want: []string{"F4-BD-9E-00-11-22"},
+		},
+		{
+			name: "bare hex disabled",
+			s:    "client f4bd9e001122 connected",
+			n:    NotationCommon,
+		},
+		{
+			name: "bare hex",
+			s:    "client f4bd9e001122 connected",
+			n:    NotationCommon | NotationBare,
+			want: []string{"f4bd9e001122"},
+		},
+		{
+			name: "longer hex sequences",
+			s:    "sha 0123456789abcdef0123 eui64 00:11:22:33:44:55:66:77 ipv6 fe80:aa:bb:cc:dd:ee:ff",
+			n:    NotationCommon | NotationBare,
+		},
+		{
+			name: "hex letters before the address",
+			s:    "MAC:aa:bb:cc:dd:ee:ff ID-00-1b-21-0a-bc-de",
+			n:    NotationCommon,
+			want: []string{"aa:bb:cc:dd:ee:ff", "00-1b-21-0a-bc-de"},
+		},
+		{
+			name: "hex letters after the address",
+			s:    "src=aa:bb:cc:dd:ee:ff.Done 00:11:22:33:44:55:ab",
+			n:    NotationCommon,
+			want: []string{"aa:bb:cc:dd:ee:ff"},
+		},
+		{
+			name: "only colon",
+			s:    "f4:bd:9e:00:11:22 f4-bd-9e-00-11-22",
+			n:    NotationColon,
+			want: []string{"f4:bd:9e:00:11:22"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			var got []string
+			for _, m := range FindAll(tc.s, tc.n) {
+				got = append(got, tc.s[m[0]:m[1]])
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package macaddr
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// Parse parses s as 48 bit hardware address. In addition to the notations
+// accepted by net.ParseMAC, bare hex digits (aabbccddeeff) and octets with a
+// single digit (0:1b:c:dd:e:ff) are accepted.
+func Parse(s string) (net.HardwareAddr, error) {
+	s = strings.TrimSpace(s)
+	if mac, err := net.ParseMAC(s); err == nil && len(mac) == 6 {
+		return mac, nil
+	}
+	var octets []string
+	switch {
+	case len(s) == 12:
+		for i := 0; i < len(s); i += 2 {
+			octets = append(octets, s[i:i+2])
+		}
+	case strings.Count(s, ":") == 5:
+		octets = strings.Split(s, ":")
+	case strings.Count(s, "-") == 5:
+		octets = strings.Split(s, "-")
+	default:
+		return nil, fmt.Errorf("invalid hardware address %q", s)
+	}
+	mac := make(net.HardwareAddr, 0, 6)
+	for _, o := range octets {
+		b, ok := parseOctet(o)
+		if !ok {
+			return nil, fmt.Errorf("invalid hardware address %q", s)
+		}
+		mac = append(mac, b)
+	}
+	return mac, nil
+}
+
+func parseOctet(s string) (byte, bool) {
+	if len(s) == 0 || len(s) > 2 {
+		return 0, false
+	}
+	var b byte
+	for i := 0; i < len(s); i++ {
+		d, ok := hexDigit(s[i])
+		if !ok {
+			return 0, false
+		}
+		b = b<<4 | d
+	}
+	return b, true
+}
+
+func hexDigit(c byte) (byte, bool) {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0', true
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10, true
+	case 'A' <= c && c <= 'F':
+		return c - 'A' + 10, true
+	}
+	return 0, false
+}
@@ -0,0 +1,41 @@
+package macaddr
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParse(t *testing.T) {
+	want := net.HardwareAddr{0x00, 0x1b, 0x0c, 0xdd, 0x0e, 0xff}
+	tt := []struct {
+		in      string
+		want    net.HardwareAddr
+		wantErr bool
+	}{
+		{in: "00:1b:0c:dd:0e:ff", want: want},
+		{in: "00-1B-0C-DD-0E-FF", want: want},
+		{in: "001b.0cdd.0eff", want: want},
+		{in: "001B0CDD0EFF", want: want},
+		{in: "0:1b:c:dd:e:ff", want: want},
+		{in: " 00:1b:0c:dd:0e:ff\t", want: want},
+		{in: "00:1b:0c:dd:0e", wantErr: true},
+		{in: "00:1b:0c:dd:0e:fg", wantErr: true},
+		{in: "00:1b:0c:dd:0e:fff", wantErr: true},
+		{in: "00:00:00:00:00:00:00:01", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := Parse(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Parse() error = %v, wantErr %v", err, tc.wantErr)
+				return
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package macpack
+
+import "strings"
+
+// countryCodes contains the ISO 3166-1 alpha-2 codes.
+var countryCodes = func() map[string]struct{} {
+	codes := make(map[string]struct{})
+	for _, c := range strings.Fields(isoCodes) {
+		codes[c] = struct{}{}
+	}
+	return codes
+}()
+
+const isoCodes = `
+AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ
+BL BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR
+CU CV CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR
+GA GB GD GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU
+ID IE IL IM IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN KP KR KW KY KZ
+LA LB LC LI LK LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ
+MR MS MT MU MV MW MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF
+PG PH PK PL PM PN PR PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI
+SJ SK SL SM SN SO SR SS ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR
+TT TV TW TZ UA UG UM US UY UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW`
+
+// countryWindow limits how many trailing tokens of an address are searched
+// for the country code. Postal codes consist of up to three tokens.
+const countryWindow = 4
+
+// parseAddress extracts the country code and the postal code of an address
+// as published by the IEEE. The addresses end with the ISO country code
+// followed by the postal code, e.g. "Ferndale WA US 98248". Postal codes may
+// contain letters that are valid country codes as well, e.g. "Rotterdam ZH NL
+// 3047 AL", so the candidates within the last tokens are chosen in this order:
+// - the rightmost code followed by a postal code containing a digit
+// - the leftmost code followed only by postal tokens that are no country codes
+// - the rightmost code
+// The tokens after the code form the postal code. If no code is found, both
+// are empty.
+func parseAddress(addr string) (country, postal string) {
+	f := strings.Fields(addr)
+	var candidates []int
+	for i := len(f) - 1; i >= 0 && i >= len(f)-countryWindow; i-- {
+		if _, ok := countryCodes[f[i]]; ok {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", ""
+	}
+	pick := candidates[0]
+	if i, ok := pickCountry(f, candidates); ok {
+		pick = i
+	}
+	return f[pick], strings.Join(f[pick+1:], " ")
+}
+
+// pickCountry applies the first two rules of parseAddress to the candidates,
+// which are ordered from right to left.
+func pickCountry(f []string, candidates []int) (int, bool) {
+	for _, i := range candidates {
+		if isPostalCode(f[i+1:]) && strings.ContainsAny(strings.Join(f[i+1:], ""), "0123456789") {
+			return i, true
+		}
+	}
+	for j := len(candidates) - 1; j >= 0; j-- {
+		i := candidates[j]
+		if len(f) > i+1 && isPostalCode(f[i+1:]) && !isCountryCode(f[i+1:]) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// isPostalCode reports whether all tokens look like parts of a postal code,
+// e.g. "1101 AA", "BS14 0AF" or "SE- 221 00".
+func isPostalCode(tokens []string) bool {
+	for _, t := range tokens {
+		digits := strings.ContainsAny(t, "0123456789")
+		if len(t) > 10 || !digits && len(t) > 3 {
+			return false
+		}
+		for _, c := range t {
+			if !(c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// isCountryCode reports whether any of the tokens is a country code.
+func isCountryCode(tokens []string) bool {
+	for _, t := range tokens {
+		if _, ok := countryCodes[t]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// newOrganization returns an organization with the structured fields
+// derived from its address.
+func newOrganization(name, shortName, address string) Organization {
+	country, postal := parseAddress(address)
+	return Organization{
+		Name:       name,
+		ShortName:  shortName,
+		Address:    address,
+		Country:    country,
+		PostalCode: postal,
+	}
+}
@@ -0,0 +1,33 @@
+package macpack
+
+import "testing"
+
+func Test_parseAddress(t *testing.T) {
+	tt := []struct {
+		addr        string
+		wantCountry string
+		wantPostal  string
+	}{
+		{addr: "2181 Buchanan Loop Ferndale WA US 98248 ", wantCountry: "US", wantPostal: "98248"},
+		{addr: "Polbina st., 3/1 Moscow  RU 109388", wantCountry: "RU", wantPostal: "109388"},
+		{addr: "Burgemeester Stramanweg 105B Amsterdam  NL 1101 AA ", wantCountry: "NL", wantPostal: "1101 AA"},
+		{addr: "Western Drive Bristol Avon GB BS14 0AF ", wantCountry: "GB", wantPostal: "BS14 0AF"},
+		{addr: "BOX99 Lund Skane SE SE- 221 00 ", wantCountry: "SE", wantPostal: "SE- 221 00"},
+		{addr: "STRATUMSED K31  THE NL  ", wantCountry: "NL"},
+		{addr: "Rotterdam ZH NL 3047 AL", wantCountry: "NL", wantPostal: "3047 AL"},
+		{addr: "Hilversum NH NL 1223 TV", wantCountry: "NL", wantPostal: "1223 TV"},
+		{addr: "Eindhoven  NL 5656 AE ", wantCountry: "NL", wantPostal: "5656 AE"},
+		{addr: "AM Bahnhof 2   DE  ", wantCountry: "DE"},
+		{addr: "GMBH & CO. KG   DE  ", wantCountry: "DE"},
+		{addr: "2455 Augustine Drive Santa Clara California  95054 "},
+		{addr: ""},
+	}
+	for _, tc := range tt {
+		t.Run(tc.addr, func(t *testing.T) {
+			country, postal := parseAddress(tc.addr)
+			if country != tc.wantCountry || postal != tc.wantPostal {
+				t.Errorf("parseAddress() = %q, %q, want %q, %q", country, postal, tc.wantCountry, tc.wantPostal)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package macpack
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DB holds a MacPack that can be reloaded while it is in use, e.g. by a
+// long running service. Every reload builds a new MacPack from the options
+// and swaps it atomically, readers keep using the snapshot they got until
+// they call Get again. If a reload fails, the previous snapshot stays.
+type DB struct {
+	opts []Option
+	pack atomic.Value // *MacPack
+
+	// reload serializes reloads.
+	reload sync.Mutex
+
+	mu     sync.RWMutex
+	status Status
+	mtimes map[string]time.Time
+}
+
+// Status describes the state of a DB.
+type Status struct {
+	// Loaded is the time the current snapshot was created.
+	Loaded time.Time
+	// Checked is the time of the last reload attempt.
+	Checked time.Time
+	// Reloads counts the successful reloads after the initial load.
+	Reloads int
+	// Err is the error of the last reload attempt, nil if it succeeded.
+	Err error
+	// Len is the number of assignments of the current snapshot.
+	Len int
+	// Sources are the sources of the current snapshot.
+	Sources []SourceInfo
+}
+
+// NewDB loads a MacPack from the options, they are applied again on every
+// reload. Sources given by WithReaderSource are only read once.
+func NewDB(opts ...Option) (*DB, error) {
+	db := &DB{opts: opts}
+	if err := db.Reload(); err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// Get returns the current snapshot.
+func (db *DB) Get() *MacPack {
+	return db.pack.Load().(*MacPack)
+}
+
+// Status returns the state of the DB.
+func (db *DB) Status() Status {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	s := db.status
+	s.Sources = append([]SourceInfo(nil), s.Sources...)
+	return s
+}
+
+// Reload builds a new snapshot from the sources and replaces the current
+// one. On error the current snapshot is kept.
+func (db *DB) Reload() error {
+	db.reload.Lock()
+	defer db.reload.Unlock()
+
+	// the modification times are taken before loading, so a change during
+	// the load triggers another reload.
+	mtimes := make(map[string]time.Time)
+	if cur, ok := db.pack.Load().(*MacPack); ok {
+		for _, f := range cur.files {
+			mtimes[f] = modTime(f)
+		}
+	}
+	now := time.Now()
+	m, err := New(db.opts...)
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.status.Checked, db.status.Err = now, err
+	if err != nil {
+		// a broken file is only loaded again once it changes.
+		if db.mtimes != nil {
+			db.mtimes = mtimes
+		}
+		return err
+	}
+	for _, f := range m.files {
+		if _, ok := mtimes[f]; !ok {
+			mtimes[f] = modTime(f)
+		}
+	}
+	if !db.status.Loaded.IsZero() {
+		db.status.Reloads++
+	}
+	db.status.Loaded, db.status.Len, db.status.Sources = now, m.Len(), m.Sources()
+	db.mtimes = mtimes
+	db.pack.Store(m)
+	return nil
+}
+
+// changed reports whether a local source was modified since it was loaded.
+func (db *DB) changed() bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	for f, t := range db.mtimes {
+		if !modTime(f).Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func modTime(path string) time.Time {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return fi.ModTime()
+}
+
+// A WatchOption selects what triggers a reload of a DB.
+type WatchOption func(w *watch)
+
+type watch struct {
+	interval time.Duration
+	poll     time.Duration
+	signals  []os.Signal
+}
+
+// WatchInterval reloads the DB in the given interval, e.g. to refresh
+// remote sources.
+func WatchInterval(d time.Duration) WatchOption {
+	return func(w *watch) {
+		w.interval = d
+	}
+}
+
+// WatchFiles checks the modification time of the local sources in the given
+// interval and reloads the DB if one of them changed.
+func WatchFiles(poll time.Duration) WatchOption {
+	return func(w *watch) {
+		w.poll = poll
+	}
+}
+
+// WatchSignals reloads the DB if one of the signals is received, usually
+// syscall.SIGHUP.
+func WatchSignals(sig ...os.Signal) WatchOption {
+	return func(w *watch) {
+		w.signals = append(w.signals, sig...)
+	}
+}
+
+// Watch reloads the DB whenever one of the selected triggers fires, until
+// ctx is done. Reload errors are reported by Status.
+func (db *DB) Watch(ctx context.Context, opts ...WatchOption) {
+	var w watch
+	for _, o := range opts {
+		o(&w)
+	}
+	var interval, poll <-chan time.Time
+	if w.interval > 0 {
+		t := time.NewTicker(w.interval)
+		defer t.Stop()
+		interval = t.C
+	}
+	if w.poll > 0 {
+		t := time.NewTicker(w.poll)
+		defer t.Stop()
+		poll = t.C
+	}
+	var sig chan os.Signal
+	if len(w.signals) > 0 {
+		sig = make(chan os.Signal, 1)
+		signal.Notify(sig, w.signals...)
+		defer signal.Stop(sig)
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-interval:
+			db.Reload()
+		case <-poll:
+			if db.changed() {
+				db.Reload()
+			}
+		case <-sig:
+			db.Reload()
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package macpack
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeTestTable(t *testing.T, path, name string, mtime time.Time) {
+	t.Helper()
+	data := "Registry,Assignment,Organization Name,Organization Address\nMA-L,F4BD9E," + name + ",\n"
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDB_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "table.csv")
+	start := time.Now().Add(-time.Hour)
+	writeTestTable(t, path, "first", start)
+	db, err := NewDB(WithLocalSource(path), WithReaderSource(strings.NewReader(
+		"Registry,Assignment,Organization Name,Organization Address\nMA-L,00D0EF,IGT,\n")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a := db.Get().Get("f4:bd:9e:00:00:01"); a == nil || a.Name != "first" {
+		t.Fatalf("Get() = %+v, want first", a)
+	}
+
+	writeTestTable(t, path, "second", start.Add(time.Minute))
+	if err := db.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if a := db.Get().Get("f4:bd:9e:00:00:01"); a == nil || a.Name != "second" {
+		t.Errorf("Get() = %+v, want second", a)
+	}
+	// the reader source is applied again.
+	if a := db.Get().Get("00:d0:ef:00:00:01"); a == nil || a.Name != "IGT" {
+		t.Errorf("Get() = %+v, want IGT", a)
+	}
+
+	if err := os.WriteFile(path, []byte("Registry,Assignment,Organization Name,Organization Address\nMA-L,F4BD9X,broken,\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Reload(); err == nil {
+		t.Fatal("expected error for a broken table")
+	}
+	if a := db.Get().Get("f4:bd:9e:00:00:01"); a == nil || a.Name != "second" {
+		t.Errorf("Get() = %+v, want second after a failed reload", a)
+	}
+	s := db.Status()
+	if s.Err == nil || s.Reloads != 1 || s.Len != 2 || len(s.Sources) != 2 {
+		t.Errorf("Status() = %+v", s)
+	}
+}
+
+func TestDB_Watch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "table.csv")
+	start := time.Now().Add(-time.Hour)
+	writeTestTable(t, path, "first", start)
+	db, err := NewDB(WithLocalSource(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		db.Watch(ctx, WatchFiles(time.Millisecond))
+	}()
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	writeTestTable(t, path, "second", start.Add(time.Minute))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		// readers run concurrently with the reload.
+		if a := db.Get().Get("f4:bd:9e:00:00:01"); a != nil && a.Name == "second" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("reload did not happen, status %+v", db.Status())
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if s := db.Status(); s.Reloads != 1 || s.Err != nil {
+		t.Errorf("Status() = %+v", s)
+	}
+}
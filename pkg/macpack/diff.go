@@ -0,0 +1,110 @@
+package macpack
+
+import (
+	"strings"
+	"unicode"
+)
+
+// ChangeType describes how an assignment changed between two snapshots.
+type ChangeType string
+
+// Types of changes reported by Diff.
+const (
+	// ChangeAdded is used for prefixes that are new.
+	ChangeAdded ChangeType = "added"
+	// ChangeRemoved is used for prefixes that no longer exist.
+	ChangeRemoved ChangeType = "removed"
+	// ChangeReassigned is used if a prefix belongs to another organization.
+	ChangeReassigned ChangeType = "reassigned"
+	// ChangeRenamed is used if the organization kept its address but
+	// changed its name, or the names only differ in case and punctuation.
+	// Empty addresses, as in manuf or nmap tables, never count as kept.
+	ChangeRenamed ChangeType = "renamed"
+	// ChangeMoved is used if only the address of the organization changed.
+	ChangeMoved ChangeType = "moved"
+	// ChangeRegistry is used if a prefix moved to another registry of the
+	// same block size, e.g. from IAB to MA-S. It is reported in addition to
+	// a change of the organization.
+	ChangeRegistry ChangeType = "registry"
+)
+
+// Change of a single assignment. Old is nil for added, New is nil for
+// removed assignments. Registry is the registry of the newer snapshot, except
+// for removed assignments, OldRegistry is only set for registry changes.
+type Change struct {
+	Type        ChangeType
+	Prefix      Prefix
+	Registry    Registry
+	OldRegistry Registry
+	Old         *Organization
+	New         *Organization
+}
+
+// Diff compares two snapshots and returns the changes ordered by prefix.
+// The sources of the assignments are not compared.
+func Diff(from, to *MacPack) []Change {
+	var (
+		changes []Change
+		a       = from.Assignments()
+		b       = to.Assignments()
+	)
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || len(a) > 0 && less(a[0].Prefix, b[0].Prefix):
+			changes = append(changes, Change{Type: ChangeRemoved, Prefix: a[0].Prefix, Registry: a[0].Registry, Old: &a[0].Organization})
+			a = a[1:]
+		case len(a) == 0 || less(b[0].Prefix, a[0].Prefix):
+			changes = append(changes, Change{Type: ChangeAdded, Prefix: b[0].Prefix, Registry: b[0].Registry, New: &b[0].Organization})
+			b = b[1:]
+		default:
+			if a[0].Registry != b[0].Registry {
+				changes = append(changes, Change{Type: ChangeRegistry, Prefix: b[0].Prefix, Registry: b[0].Registry,
+					OldRegistry: a[0].Registry, Old: &a[0].Organization, New: &b[0].Organization})
+			}
+			if t, ok := compare(a[0].Organization, b[0].Organization); ok {
+				changes = append(changes, Change{Type: t, Prefix: b[0].Prefix, Registry: b[0].Registry, Old: &a[0].Organization, New: &b[0].Organization})
+			}
+			a, b = a[1:], b[1:]
+		}
+	}
+	return changes
+}
+
+func less(a, b Prefix) bool {
+	if a.Addr != b.Addr {
+		return a.Addr < b.Addr
+	}
+	return a.Bits < b.Bits
+}
+
+// compare returns the type of change between two organizations of the same
+// prefix, ok is false if they are equal.
+func compare(from, to Organization) (t ChangeType, ok bool) {
+	sameName := from.Name == to.Name
+	addr := normalizeSpace(from.Address)
+	sameAddr := addr == normalizeSpace(to.Address)
+	switch {
+	case sameName && sameAddr:
+		return "", false
+	case sameName:
+		return ChangeMoved, true
+	case sameAddr && addr != "", simplify(from.Name) == simplify(to.Name):
+		return ChangeRenamed, true
+	}
+	return ChangeReassigned, true
+}
+
+func normalizeSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// simplify reduces a name to its lower case letters and digits, e.g.
+// "Cisco Systems, Inc" and "CISCO SYSTEMS INC." are both "ciscosystemsinc".
+func simplify(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,69 @@
+package macpack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiff(t *testing.T) {
+	old, err := New(WithReaderSource(strings.NewReader(
+		`Registry,Assignment,Organization Name,Organization Address
+MA-L,002272,American Micro-Fuel Device Corp.,2181 Buchanan Loop Ferndale WA US 98248
+MA-L,00D0EF,IGT,9295 PROTOTYPE DRIVE RENO NV US 89511
+MA-L,086195,Rockwell Automation,1 Allen-Bradley Dr. Mayfield Heights OH US 44124-6118
+MA-L,F4BD9E,"Cisco Systems, Inc",80 West Tasman Drive San Jose CA US 94568
+MA-M,741AE09,Private,
+MA-S,70B3D5F2F,TELEPLATFORMS,"Polbina st., 3/1 Moscow  RU 109388"
+IAB,0050C2F1C,Krontek Pty,
+IAB,0050C2F1D,Example Ltd,`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	updated, err := New(WithReaderSource(strings.NewReader(
+		`Registry,Assignment,Organization Name,Organization Address
+MA-L,002272,American Micro-Fuel Device Corp.,2181  Buchanan Loop Ferndale WA US 98248
+MA-L,00D0EF,Light & Wonder,9295 PROTOTYPE DRIVE RENO NV US 89511
+MA-L,086195,Rockwell Automation,1 Allen-Bradley Dr. Milwaukee WI US 53204
+MA-L,F4BD9E,CISCO SYSTEMS INC.,170 West Tasman Drive San Jose CA US 95134
+MA-M,741AE09,Example Corp,1 Example Road Berlin DE 10115
+MA-S,70B3D5719,2M Technology,802 Greenview Drive  Grand Prairie TX US 75050
+MA-S,0050C2F1C,Krontek Pty,
+MA-S,0050C2F1D,Other Ltd,`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type change struct {
+		Type                  ChangeType
+		Prefix                string
+		Registry, OldRegistry Registry
+		Old, New              string
+	}
+	want := []change{
+		{Type: ChangeRegistry, Prefix: "0050c2f1c", Registry: RegistryMAS, OldRegistry: RegistryIAB, Old: "Krontek Pty", New: "Krontek Pty"},
+		{Type: ChangeRegistry, Prefix: "0050c2f1d", Registry: RegistryMAS, OldRegistry: RegistryIAB, Old: "Example Ltd", New: "Other Ltd"},
+		{Type: ChangeReassigned, Prefix: "0050c2f1d", Registry: RegistryMAS, Old: "Example Ltd", New: "Other Ltd"},
+		{Type: ChangeRenamed, Prefix: "00d0ef", Registry: RegistryMAL, Old: "IGT", New: "Light & Wonder"},
+		{Type: ChangeMoved, Prefix: "086195", Registry: RegistryMAL, Old: "Rockwell Automation", New: "Rockwell Automation"},
+		{Type: ChangeAdded, Prefix: "70b3d5719", Registry: RegistryMAS, New: "2M Technology"},
+		{Type: ChangeRemoved, Prefix: "70b3d5f2f", Registry: RegistryMAS, Old: "TELEPLATFORMS"},
+		{Type: ChangeReassigned, Prefix: "741ae09", Registry: RegistryMAM, Old: "Private", New: "Example Corp"},
+		{Type: ChangeRenamed, Prefix: "f4bd9e", Registry: RegistryMAL, Old: "Cisco Systems, Inc", New: "CISCO SYSTEMS INC."},
+	}
+	var got []change
+	for _, c := range Diff(old, updated) {
+		gc := change{Type: c.Type, Prefix: c.Prefix.String(), Registry: c.Registry, OldRegistry: c.OldRegistry}
+		if c.Old != nil {
+			gc.Old = c.Old.Name
+		}
+		if c.New != nil {
+			gc.New = c.New.Name
+		}
+		got = append(got, gc)
+	}
+	if !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
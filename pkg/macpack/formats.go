@@ -0,0 +1,184 @@
+package macpack
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// format of a vendor database.
+type format int
+
+const (
+	// formatCSV is the IEEE layout:
+	// Registry,Assignment,Organization Name,Organization Address
+	formatCSV format = iota
+	// formatIndex is the binary index written by WriteIndex.
+	formatIndex
+	// formatManuf is the Wireshark manuf file:
+	// 00:1B:C5:00:00:00/36<TAB>Convergi<TAB>Converging Systems Inc.
+	formatManuf
+	// formatNmap is the nmap-mac-prefixes file:
+	// 0050C2 IEEE Registration Authority
+	formatNmap
+	// formatWellKnown is the table of well-known ranges:
+	// Prefix,Type,Name
+	formatWellKnown
+)
+
+// gzipMagic starts every gzip stream.
+const gzipMagic = "\x1f\x8b"
+
+// isGzip reports whether the source is gzip compressed. Compression is
+// independent of the format, so it is checked before detect.
+func isGzip(r *bufio.Reader) bool {
+	b, err := r.Peek(len(gzipMagic))
+	return err == nil && string(b) == gzipMagic
+}
+
+// detectSize limits how far a source is read ahead to detect its format.
+const detectSize = 64 << 10
+
+// detect peeks at the first entry of a source to determine its format.
+// Comments and empty lines are skipped, if nothing matches csv is assumed.
+func detect(r *bufio.Reader) format {
+	if isIndex(r) {
+		return formatIndex
+	}
+	b, _ := r.Peek(detectSize)
+	for _, line := range strings.Split(string(b), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		if strings.HasPrefix(strings.ToLower(line), "registry,") {
+			return formatCSV
+		}
+		if strings.HasPrefix(strings.ToLower(line), "prefix,type,") {
+			return formatWellKnown
+		}
+		f := strings.Fields(line)[0]
+		if strings.ContainsAny(f, ":-") {
+			return formatManuf
+		}
+		if _, err := parseDigits(f); err == nil && len(f) >= 6 {
+			return formatNmap
+		}
+		return formatCSV
+	}
+	return formatCSV
+}
+
+// parseManuf decodes the Wireshark manuf file. Each line holds the prefix,
+// a short name and optionally the full name of the organization. Older
+// versions append the full name as comment. The registry is derived from
+// the length of the prefix.
+func parseManuf(r io.Reader, source string) ([]Assignment, error) {
+	var as []Assignment
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		var fields []string
+		for _, f := range strings.Split(text, "\t") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("manuf: line %d: missing organization", line)
+		}
+		p, err := ParsePrefix(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("manuf: line %d: %w", line, err)
+		}
+		short, name := fields[1], ""
+		if len(fields) > 2 {
+			name = fields[2]
+		}
+		if i := strings.IndexByte(short, '#'); i >= 0 {
+			short, name = strings.TrimSpace(short[:i]), short[i+1:]
+		}
+		name = strings.TrimSpace(strings.TrimPrefix(name, "#"))
+		if name == "" {
+			name = short
+		}
+		as = append(as, Assignment{
+			Prefix:       p,
+			Registry:     registryOf(p.Bits),
+			Source:       source,
+			Organization: newOrganization(name, short, ""),
+		})
+	}
+	return as, s.Err()
+}
+
+// parseNmap decodes the nmap-mac-prefixes file. Each line holds the hex
+// digits of the prefix followed by the name of the organization.
+func parseNmap(r io.Reader, source string) ([]Assignment, error) {
+	var as []Assignment
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		digits, name, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("nmap: line %d: missing organization", line)
+		}
+		p, err := parseDigits(digits)
+		if err != nil {
+			return nil, fmt.Errorf("nmap: line %d: %w", line, err)
+		}
+		as = append(as, Assignment{
+			Prefix:       p,
+			Registry:     registryOf(p.Bits),
+			Source:       source,
+			Organization: newOrganization(strings.TrimSpace(name), "", ""),
+		})
+	}
+	return as, s.Err()
+}
+
+// parseWellKnown decodes a table of well-known ranges. Each row holds the
+// prefix with an optional mask, the kind of the range and a description,
+// which is used as organization name.
+func parseWellKnown(r io.Reader, source string) ([]Assignment, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = 3
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+	var as []Assignment
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			return as, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		p, err := ParsePrefix(strings.TrimSpace(rec[0]))
+		if err != nil {
+			return nil, fmt.Errorf("well-known: line %d: %w", line, err)
+		}
+		k, err := ParseKind(strings.TrimSpace(rec[1]))
+		if err != nil {
+			return nil, fmt.Errorf("well-known: line %d: %w", line, err)
+		}
+		as = append(as, Assignment{
+			Prefix:       p,
+			Registry:     RegistryWellKnown,
+			Source:       source,
+			Kind:         k,
+			Organization: newOrganization(strings.TrimSpace(rec[2]), "", ""),
+		})
+	}
+}
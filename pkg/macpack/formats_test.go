@@ -0,0 +1,262 @@
+package macpack
+
+import (
+	"bytes"
+	"compress/gzip"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/frzifus/vlookup/pkg/tables"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFormats(t *testing.T) {
+	tt := []struct {
+		name    string
+		src     string
+		want    []Assignment
+		wantErr bool
+	}{
+		{
+			name: "ieee iab csv",
+			src: `Registry,Assignment,Organization Name,Organization Address
+IAB,0050C2F1C,Krontek Pty Ltd,Level 7 Mitchell Place Brisbane QLD AU 4000`,
+			want: []Assignment{
+				{
+					Prefix: Prefix{Addr: 0x0050c2f1c000, Bits: 36}, Registry: RegistryIAB, Source: "test",
+					Organization: Organization{Name: "Krontek Pty Ltd", Address: "Level 7 Mitchell Place Brisbane QLD AU 4000",
+						Country: "AU", PostalCode: "4000"},
+				},
+			},
+		},
+		{
+			name: "wireshark manuf",
+			src: `# This file was generated by TShark.
+#
+00:00:01	Xerox	Xerox Corporation
+00:00:0C	Cisco	# Cisco Systems, Inc
+00:1B:C5:00:00:00/36	Converging	Converging Systems Inc.
+00:55:DA:00:00:00/28	Shinko	Shinko Technos co.,ltd.
+08:00:2B	DEC
+`,
+			want: []Assignment{
+				{
+					Prefix: Prefix{Addr: 0x000001000000, Bits: 24}, Registry: RegistryMAL, Source: "test",
+					Organization: Organization{Name: "Xerox Corporation", ShortName: "Xerox"},
+				},
+				{
+					Prefix: Prefix{Addr: 0x00000c000000, Bits: 24}, Registry: RegistryMAL, Source: "test",
+					Organization: Organization{Name: "Cisco Systems, Inc", ShortName: "Cisco"},
+				},
+				{
+					Prefix: Prefix{Addr: 0x001bc5000000, Bits: 36}, Registry: RegistryMAS, Source: "test",
+					Organization: Organization{Name: "Converging Systems Inc.", ShortName: "Converging"},
+				},
+				{
+					Prefix: Prefix{Addr: 0x0055da000000, Bits: 28}, Registry: RegistryMAM, Source: "test",
+					Organization: Organization{Name: "Shinko Technos co.,ltd.", ShortName: "Shinko"},
+				},
+				{
+					Prefix: Prefix{Addr: 0x08002b000000, Bits: 24}, Registry: RegistryMAL, Source: "test",
+					Organization: Organization{Name: "DEC", ShortName: "DEC"},
+				},
+			},
+		},
+		{
+			name:    "wireshark manuf invalid mask",
+			src:     "00:1B:C5:00:00:00/64\tConverging\tConverging Systems Inc.",
+			wantErr: true,
+		},
+		{
+			name: "nmap prefixes",
+			src: `# $Id$ generated with make-mac-prefixes.pl
+000000 Xerox
+0050C2F1C Krontek Pty
+`,
+			want: []Assignment{
+				{
+					Prefix: Prefix{Addr: 0x000000000000, Bits: 24}, Registry: RegistryMAL, Source: "test",
+					Organization: Organization{Name: "Xerox"},
+				},
+				{
+					Prefix: Prefix{Addr: 0x0050c2f1c000, Bits: 36}, Registry: RegistryMAS, Source: "test",
+					Organization: Organization{Name: "Krontek Pty"},
+				},
+			},
+		},
+		{
+			name: "well-known",
+			src: `Prefix,Type,Name
+# comment
+52:54:00,virtual-nic,KVM guest
+00:00:5e:00:01/40,virtual-router,VRRP virtual router
+`,
+			want: []Assignment{
+				{
+					Prefix: Prefix{Addr: 0x00005e000100, Bits: 40}, Registry: RegistryWellKnown, Source: "test",
+					Kind: KindVirtualRouter, Organization: Organization{Name: "VRRP virtual router"},
+				},
+				{
+					Prefix: Prefix{Addr: 0x525400000000, Bits: 24}, Registry: RegistryWellKnown, Source: "test",
+					Kind: KindVirtualNIC, Organization: Organization{Name: "KVM guest"},
+				},
+			},
+		},
+		{
+			name:    "well-known unknown kind",
+			src:     "Prefix,Type,Name\n52:54:00,hypervisor,KVM guest\n",
+			wantErr: true,
+		},
+		{
+			name:    "nmap missing organization",
+			src:     "000000\n",
+			wantErr: true,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			m, err := New(func(m *MacPack) error {
+				return m.load(strings.NewReader(tc.src), "test")
+			})
+			if (err != nil) != tc.wantErr {
+				t.Errorf("load() error = %v, wantErr %v", err, tc.wantErr)
+				return
+			}
+			if err != nil {
+				return
+			}
+			got := m.Assignments()
+			if len(got) == 0 {
+				got = m.WellKnown()
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+		})
+	}
+}
+
+func TestGzip(t *testing.T) {
+	m, err := New(WithLocalSource("../tables/oui36.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var idx bytes.Buffer
+	if err := m.WriteIndex(&idx); err != nil {
+		t.Fatal(err)
+	}
+	csv, err := os.ReadFile("../tables/oui36.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string][]byte{"csv": csv, "index": idx.Bytes()} {
+		t.Run(name, func(t *testing.T) {
+			var b bytes.Buffer
+			zw := gzip.NewWriter(&b)
+			zw.Write(data)
+			if err := zw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			got, err := New(WithReaderSource(&b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			plain, err := New(WithReaderSource(bytes.NewReader(data)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := plain.Assignments()
+			if len(want) != m.Len() {
+				t.Fatalf("got %d assignments, want %d", len(want), m.Len())
+			}
+			if !cmp.Equal(got.Assignments(), want) {
+				t.Error(cmp.Diff(got.Assignments(), want))
+			}
+		})
+	}
+
+	if _, err := New(WithReaderSource(strings.NewReader("\x1f\x8bnot gzip"))); err == nil {
+		t.Error("expected error for a broken gzip stream")
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	tt := []struct {
+		in      string
+		want    Prefix
+		wantErr bool
+	}{
+		{in: "70B3D5", want: Prefix{Addr: 0x70b3d5000000, Bits: 24}},
+		{in: "00:1B:C5:00:00:00/36", want: Prefix{Addr: 0x001bc5000000, Bits: 36}},
+		{in: "01:00:5E:FF/25", want: Prefix{Addr: 0x01005e800000, Bits: 25}},
+		{in: "01:00:5E/25", wantErr: true},
+		{in: "01:00:5E/x", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "aa:bb:cc:dd:ee:ff:00", wantErr: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.in, func(t *testing.T) {
+			got, err := ParsePrefix(tc.in)
+			if (err != nil) != tc.wantErr {
+				t.Errorf("ParsePrefix() error = %v, wantErr %v", err, tc.wantErr)
+				return
+			}
+			if got != tc.want {
+				t.Errorf("ParsePrefix() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWellKnownTable(t *testing.T) {
+	m, err := New(
+		WithFSSource(tables.Get(), tables.MACLarge),
+		WithFSSource(tables.Get(), tables.WellKnown),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt := []struct {
+		addr     string
+		wantName string
+		wantKind Kind
+		// wantKnown is the description of the well-known range.
+		wantKnown string
+	}{
+		{addr: "52:54:00:12:34:56", wantName: "KVM guest", wantKind: KindVirtualNIC, wantKnown: "KVM guest"},
+		{addr: "02:42:ac:11:00:02", wantName: "Docker container", wantKind: KindVirtualNIC, wantKnown: "Docker container"},
+		{addr: "01:00:5e:7f:ff:fa", wantName: "IPv4 multicast", wantKind: KindMulticast, wantKnown: "IPv4 multicast"},
+		{addr: "33:33:00:00:00:01", wantName: "IPv6 multicast", wantKind: KindMulticast, wantKnown: "IPv6 multicast"},
+		{addr: "01:80:c2:00:00:0e", wantName: "LLDP", wantKind: KindProtocol, wantKnown: "LLDP"},
+		{addr: "01:80:c2:00:00:0a", wantName: "IEEE 802.1 link-local", wantKind: KindProtocol, wantKnown: "IEEE 802.1 link-local"},
+		// ranges of a vendor keep their vendor.
+		{addr: "08:00:27:00:00:01", wantName: "PCS Systemtechnik GmbH", wantKind: KindVendor, wantKnown: "VirtualBox guest"},
+		{addr: "00:50:56:00:00:01", wantName: "VMware, Inc.", wantKind: KindVendor, wantKnown: "VMware guest"},
+		{addr: "00:15:5d:00:00:01", wantName: "Microsoft Corporation", wantKind: KindVendor, wantKnown: "Hyper-V guest"},
+		{addr: "00:00:0c:07:ac:01", wantName: "Cisco Systems, Inc", wantKind: KindVendor, wantKnown: "HSRP virtual router"},
+		{addr: "00:00:0c:12:34:56", wantName: "Cisco Systems, Inc", wantKind: KindVendor},
+	}
+	for _, tc := range tt {
+		t.Run(tc.addr, func(t *testing.T) {
+			a := m.Get(tc.addr)
+			if a == nil {
+				t.Fatal("not found")
+			}
+			if a.Name != tc.wantName || a.Kind != tc.wantKind {
+				t.Errorf("Get() = %s (%s), want %s (%s)", a.Name, a.Kind, tc.wantName, tc.wantKind)
+			}
+			p, err := ParsePrefix(tc.addr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			k, _ := m.LookupWellKnown(p)
+			if k.Name != tc.wantKnown {
+				t.Errorf("LookupWellKnown() = %q, want %q", k.Name, tc.wantKnown)
+			}
+		})
+	}
+	if got := len(m.Conflicts()); got != 0 {
+		t.Errorf("got %d conflicts, want 0", got)
+	}
+}
@@ -0,0 +1,297 @@
+package macpack
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// The binary index is a precompiled representation of a MacPack. It starts
+// with a fixed size header followed by the payload:
+//
+//	magic    [4]byte "VLIX"
+//	version  uint16
+//	flags    uint16, reserved
+//	count    uint32, number of assignments
+//	size     uint32, length of the payload in bytes
+//	checksum uint32, crc32 (IEEE) of the payload
+//
+// All header fields are little endian. The payload contains a string table
+// of registries and sources, a string table of the organizations, the
+// organizations as triples of references into the latter (name, short name
+// and address) and the assignments ordered by prefix. An assignment consists
+// of the prefix, its length, references to organization, registry and source
+// and its kind. Numbers are stored as unsigned varints, prefixes as the delta
+// to their predecessor.
+const (
+	indexMagic      = "VLIX"
+	indexVersion    = 4
+	indexHeaderSize = 20
+	// maxIndexStrings limits the registries and sources, which are referenced
+	// by 16 bits in memory.
+	maxIndexStrings = 1 << 16
+)
+
+// ErrInvalidIndex is returned if a binary index is malformed.
+var ErrInvalidIndex = errors.New("invalid index")
+
+// WriteIndex writes the assignments as binary index to w. The output is
+// deterministic, so it can be committed and compared.
+func (m *MacPack) WriteIndex(w io.Writer) error {
+	var (
+		strs, text stringTable
+		orgs       []Organization
+		orgIdx     = make(map[Organization]uint64)
+	)
+	org := func(o Organization) uint64 {
+		i, ok := orgIdx[o]
+		if !ok {
+			i = uint64(len(orgs))
+			orgs = append(orgs, o)
+			orgIdx[o] = i
+		}
+		return i
+	}
+
+	var recs bytes.Buffer
+	var prev uint64
+	as := m.Assignments()
+	for _, a := range as {
+		putUvarint(&recs, a.Prefix.Addr-prev)
+		recs.WriteByte(byte(a.Prefix.Bits))
+		putUvarint(&recs, org(a.Organization))
+		putUvarint(&recs, strs.ref(string(a.Registry)))
+		putUvarint(&recs, strs.ref(a.Source))
+		recs.WriteByte(byte(a.Kind))
+		prev = a.Prefix.Addr
+	}
+	var orgTable bytes.Buffer
+	putUvarint(&orgTable, uint64(len(orgs)))
+	for _, o := range orgs {
+		putUvarint(&orgTable, text.ref(o.Name))
+		putUvarint(&orgTable, text.ref(o.ShortName))
+		putUvarint(&orgTable, text.ref(o.Address))
+	}
+	if len(strs.strs) > maxIndexStrings {
+		return fmt.Errorf("%d registries and sources exceed the string table", len(strs.strs))
+	}
+
+	var payload bytes.Buffer
+	strs.writeTo(&payload)
+	text.writeTo(&payload)
+	orgTable.WriteTo(&payload)
+	recs.WriteTo(&payload)
+
+	header := make([]byte, indexHeaderSize)
+	copy(header, indexMagic)
+	binary.LittleEndian.PutUint16(header[4:], indexVersion)
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(as)))
+	binary.LittleEndian.PutUint32(header[12:], uint32(payload.Len()))
+	binary.LittleEndian.PutUint32(header[16:], crc32.ChecksumIEEE(payload.Bytes()))
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+	_, err := payload.WriteTo(w)
+	return err
+}
+
+// stringTable numbers distinct strings in the order they are referenced.
+type stringTable struct {
+	strs []string
+	idx  map[string]uint64
+}
+
+func (t *stringTable) ref(s string) uint64 {
+	if t.idx == nil {
+		t.idx = make(map[string]uint64)
+	}
+	i, ok := t.idx[s]
+	if !ok {
+		i = uint64(len(t.strs))
+		t.strs = append(t.strs, s)
+		t.idx[s] = i
+	}
+	return i
+}
+
+func (t *stringTable) writeTo(b *bytes.Buffer) {
+	putUvarint(b, uint64(len(t.strs)))
+	for _, s := range t.strs {
+		putUvarint(b, uint64(len(s)))
+		b.WriteString(s)
+	}
+}
+
+func isIndex(r *bufio.Reader) bool {
+	b, err := r.Peek(len(indexMagic))
+	return err == nil && string(b) == indexMagic
+}
+
+// index holds the decoded tables of a binary index. The records reference
+// the organizations and the string table of registries and sources by
+// position.
+type index struct {
+	strs    []string
+	orgs    []Organization
+	records []record
+}
+
+func parseIndex(r io.Reader) (*index, error) {
+	header := make([]byte, indexHeaderSize)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+	}
+	if string(header[:4]) != indexMagic {
+		return nil, fmt.Errorf("%w: bad magic", ErrInvalidIndex)
+	}
+	if v := binary.LittleEndian.Uint16(header[4:]); v != indexVersion {
+		return nil, fmt.Errorf("%w: unsupported version %d", ErrInvalidIndex, v)
+	}
+	count := binary.LittleEndian.Uint32(header[8:])
+	size := binary.LittleEndian.Uint32(header[12:])
+	if count > size {
+		return nil, fmt.Errorf("%w: %d assignments exceed payload", ErrInvalidIndex, count)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, err)
+	}
+	if crc32.ChecksumIEEE(payload) != binary.LittleEndian.Uint32(header[16:]) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidIndex)
+	}
+
+	d := indexDecoder{buf: payload, data: string(payload)}
+	ix := &index{}
+	ix.strs = d.strings()
+	if len(ix.strs) > maxIndexStrings {
+		d.err = fmt.Errorf("%d registries and sources exceed the string table", len(ix.strs))
+	}
+	text := d.strings()
+	ix.orgs = make([]Organization, d.count())
+	for i := range ix.orgs {
+		ix.orgs[i] = newOrganization(d.str(text), d.str(text), d.str(text))
+	}
+	ix.records = make([]record, 0, count)
+	var addr uint64
+	var prev uint8
+	for i := uint32(0); i < count && d.err == nil; i++ {
+		delta := d.uvarint()
+		addr += delta
+		bits := d.byte()
+		if bits > addrBits || addr >= 1<<addrBits {
+			d.err = fmt.Errorf("prefix %x/%d out of range", addr, bits)
+			break
+		}
+		if i > 0 && delta == 0 && bits <= prev {
+			d.err = fmt.Errorf("prefix %x/%d is not in order", addr, bits)
+			break
+		}
+		prev = bits
+		key, n := Prefix{Addr: addr, Bits: int(bits)}.key()
+		ix.records = append(ix.records, record{
+			key:      key,
+			bits:     n,
+			org:      uint32(d.ref(len(ix.orgs))),
+			registry: uint16(d.ref(len(ix.strs))),
+			source:   uint16(d.ref(len(ix.strs))),
+			kind:     Kind(d.byte()),
+		})
+	}
+	if d.err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidIndex, d.err)
+	}
+	return ix, nil
+}
+
+type indexDecoder struct {
+	buf []byte
+	// data is the payload converted once, all strings reference it.
+	data string
+	off  int
+	err  error
+}
+
+func (d *indexDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.buf[d.off:])
+	if n <= 0 {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	d.off += n
+	return v
+}
+
+// count reads the length of a table, each entry takes at least one byte.
+func (d *indexDecoder) count() uint64 {
+	n := d.uvarint()
+	if n > uint64(len(d.buf)-d.off) {
+		if d.err == nil {
+			d.err = fmt.Errorf("table length %d exceeds payload", n)
+		}
+		return 0
+	}
+	return n
+}
+
+func (d *indexDecoder) byte() byte {
+	if d.err != nil {
+		return 0
+	}
+	if d.off >= len(d.buf) {
+		d.err = io.ErrUnexpectedEOF
+		return 0
+	}
+	d.off++
+	return d.buf[d.off-1]
+}
+
+// strings reads a string table.
+func (d *indexDecoder) strings() []string {
+	strs := make([]string, d.count())
+	for i := range strs {
+		n := d.uvarint()
+		if d.err == nil && n > uint64(len(d.data)-d.off) {
+			d.err = io.ErrUnexpectedEOF
+		}
+		if d.err != nil {
+			return strs
+		}
+		strs[i] = d.data[d.off : d.off+int(n)]
+		d.off += int(n)
+	}
+	return strs
+}
+
+// str reads a reference into the string table.
+func (d *indexDecoder) str(strs []string) string {
+	i := d.ref(len(strs))
+	if d.err != nil {
+		return ""
+	}
+	return strs[i]
+}
+
+// ref reads a reference into a table of length n.
+func (d *indexDecoder) ref(n int) int {
+	i := d.uvarint()
+	if d.err != nil {
+		return 0
+	}
+	if i >= uint64(n) {
+		d.err = fmt.Errorf("reference %d out of range", i)
+		return 0
+	}
+	return int(i)
+}
+
+func putUvarint(b *bytes.Buffer, v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	b.Write(tmp[:binary.PutUvarint(tmp[:], v)])
+}
@@ -0,0 +1,161 @@
+package macpack
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWriteIndex(t *testing.T) {
+	m, err := New(WithLocalSource("../tables/oui36.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := m.WriteIndex(&b); err != nil {
+		t.Fatal(err)
+	}
+	idx := b.Bytes()
+
+	got, err := New(WithReaderSource(bytes.NewReader(idx)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got.Assignments(), m.Assignments()) {
+		t.Error(cmp.Diff(got.Assignments(), m.Assignments()))
+	}
+
+	merged, err := New(
+		withTestEntries(map[string]Organization{"aabbcc": {Name: "test-name"}}),
+		WithReaderSource(bytes.NewReader(idx)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if merged.Len() != m.Len()+1 {
+		t.Errorf("Len() = %d, want %d", merged.Len(), m.Len()+1)
+	}
+	if a := merged.Get("70:B3:D5:F2:F0:00"); a == nil || a.Name != "TELEPLATFORMS" {
+		t.Errorf("Get() = %v, want TELEPLATFORMS", a)
+	}
+
+	var again bytes.Buffer
+	if err := got.WriteIndex(&again); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(again.Bytes(), idx) {
+		t.Error("index is not deterministic")
+	}
+}
+
+func TestWriteIndex_ManyOrganizations(t *testing.T) {
+	// names and addresses alone exceed the 1<<16 registries and sources.
+	entries := make(map[string]Organization, 40000)
+	for i := 0; i < 40000; i++ {
+		entries[fmt.Sprintf("%06x", i)] = Organization{
+			Name:    fmt.Sprintf("Vendor %d", i),
+			Address: fmt.Sprintf("Street %d Reno NV US 89511", i),
+		}
+	}
+	m, err := New(withTestEntries(entries))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := m.WriteIndex(&b); err != nil {
+		t.Fatal(err)
+	}
+	got, err := New(WithReaderSource(&b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cmp.Equal(got.Assignments(), m.Assignments()) {
+		t.Error(cmp.Diff(got.Assignments(), m.Assignments()))
+	}
+}
+
+func TestParseIndex(t *testing.T) {
+	m, err := New(withTestEntries(map[string]Organization{
+		"aabbcc": {Name: "test-name", Address: "East"},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := m.WriteIndex(&b); err != nil {
+		t.Fatal(err)
+	}
+	valid := b.Bytes()
+	modify := func(f func([]byte) []byte) []byte {
+		return f(append([]byte(nil), valid...))
+	}
+
+	tt := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{
+			name: "valid",
+			data: valid,
+		},
+		{
+			name:    "truncated header",
+			data:    valid[:indexHeaderSize-1],
+			wantErr: ErrInvalidIndex,
+		},
+		{
+			name:    "truncated payload",
+			data:    valid[:len(valid)-1],
+			wantErr: ErrInvalidIndex,
+		},
+		{
+			name:    "unsupported version",
+			data:    modify(func(b []byte) []byte { b[4] = 0xff; return b }),
+			wantErr: ErrInvalidIndex,
+		},
+		{
+			name:    "checksum mismatch",
+			data:    modify(func(b []byte) []byte { b[len(b)-1] ^= 0xff; return b }),
+			wantErr: ErrInvalidIndex,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := parseIndex(bytes.NewReader(tc.data))
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("parseIndex() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func BenchmarkParse(b *testing.B) {
+	csv, err := os.ReadFile("../tables/oui.csv")
+	if err != nil {
+		b.Fatal(err)
+	}
+	m, err := New(WithReaderSource(bytes.NewReader(csv)))
+	if err != nil {
+		b.Fatal(err)
+	}
+	var idx bytes.Buffer
+	if err := m.WriteIndex(&idx); err != nil {
+		b.Fatal(err)
+	}
+
+	for name, data := range map[string][]byte{"csv": csv, "index": idx.Bytes()} {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := New(WithReaderSource(bytes.NewReader(data))); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package macpack
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Labels maps hardware addresses or prefixes to user defined labels, e.g.
+// "3rd floor printer" or "lab switch stack". Like assignments, the longest
+// matching prefix wins, so a label of a single device overrides the label of
+// its range.
+type Labels struct {
+	trie   trie
+	labels []string
+}
+
+// ParseLabels reads labels with one entry per line. An entry consists of an
+// address or prefix in any notation accepted by ParsePrefix, followed by
+// whitespace and the label. Empty lines and lines starting with # are
+// ignored, e.g.:
+//
+//	# devices
+//	00:11:22:33:44:55   3rd floor printer
+//	f4:bd:9e:12:30/40   lab switch stack
+//
+// A later entry for the same prefix replaces an earlier one.
+func ParseLabels(r io.Reader) (*Labels, error) {
+	l := &Labels{trie: newTrie()}
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		text := strings.TrimSpace(s.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		i := strings.IndexAny(text, " \t")
+		if i < 0 {
+			return nil, fmt.Errorf("labels: line %d: missing label", line)
+		}
+		p, err := ParsePrefix(text[:i])
+		if err != nil {
+			return nil, fmt.Errorf("labels: line %d: %w", line, err)
+		}
+		key, n := p.key()
+		l.trie.insert(key, n, uint32(len(l.labels)))
+		l.labels = append(l.labels, strings.TrimSpace(text[i:]))
+	}
+	return l, s.Err()
+}
+
+// LoadLabels reads the labels of a local file, see ParseLabels.
+func LoadLabels(path string) (*Labels, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseLabels(f)
+}
+
+// Get returns the label of the longest prefix that matches the address.
+// A nil Labels has no entries.
+func (l *Labels) Get(addr string) (string, bool) {
+	if l == nil {
+		return "", false
+	}
+	p, err := ParsePrefix(addr)
+	if err != nil {
+		return "", false
+	}
+	key, n := p.key()
+	i, ok := l.trie.lookup(key, n)
+	if !ok {
+		return "", false
+	}
+	return l.labels[i], true
+}
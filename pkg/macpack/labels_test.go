@@ -0,0 +1,61 @@
+package macpack
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	l, err := ParseLabels(strings.NewReader(`# devices
+00:11:22:33:44:55   3rd floor printer
+f4:bd:9e:12:30/40	lab switch stack
+F4-BD-9E-12-30-07   core switch
+
+00:11:22:33:44:55   printer, 3rd floor
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		addr   string
+		want   string
+		wantOk bool
+	}{
+		{addr: "00:11:22:33:44:55", want: "printer, 3rd floor", wantOk: true},
+		{addr: "00:11:22:33:44:56"},
+		{addr: "f4:bd:9e:12:30:01", want: "lab switch stack", wantOk: true},
+		{addr: "f4:bd:9e:12:30:07", want: "core switch", wantOk: true},
+		{addr: "f4:bd:9e:12:31:07"},
+		{addr: "invalid"},
+	}
+	for _, tt := range tests {
+		got, ok := l.Get(tt.addr)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("Get(%q) = %q, %v, want %q, %v", tt.addr, got, ok, tt.want, tt.wantOk)
+		}
+	}
+
+	var empty *Labels
+	if got, ok := empty.Get("00:11:22:33:44:55"); ok {
+		t.Errorf("nil Labels returned %q", got)
+	}
+}
+
+func TestParseLabels_Errors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "missing label", input: "00:11:22:33:44:55\n", want: "labels: line 1: missing label"},
+		{name: "invalid address", input: "# x\nzz:11 printer\n", want: "labels: line 2: "},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseLabels(strings.NewReader(tt.input))
+			if err == nil || !strings.HasPrefix(err.Error(), tt.want) {
+				t.Errorf("ParseLabels() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -1,12 +1,20 @@
 package macpack
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"context"
 	"encoding/csv"
+	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
@@ -19,34 +27,17 @@ const (
 )
 
 // An Option configures a MacPack at creation time.
-type Option func(mp MacPack) error
+type Option func(mp *MacPack) error
 
 // WithRemoteSource adds entries from a remote location to the macpack register.
 // e.g. path: http://example.com/list.csv
 // The csv source should be formatted represent the following layout:
 // - Registry,Assignment,Organization Name,Organization Address
 // - MA-L, MA-S,AAAAAAAAA, orga1, A street Moscow RU 1234
+// Wireshark manuf, nmap-mac-prefixes and binary indexes created by WriteIndex
+// are detected and accepted as well, each of them optionally gzip compressed.
 func WithRemoteSource(path string) Option {
-	return func(m MacPack) error {
-		c := &http.Client{Timeout: 30 * time.Second}
-		resp, err := c.Get(path)
-		if err != nil {
-			return err
-		}
-		defer resp.Body.Close()
-		var b bytes.Buffer
-		if _, err = io.Copy(&b, resp.Body); err != nil {
-			return err
-		}
-		mp, err := newMacPack(&b)
-		if err != nil {
-			return err
-		}
-		for k, v := range mp {
-			m[k] = v
-		}
-		return nil
-	}
+	return WithRemote(context.Background(), path, RemoteClient(&http.Client{Timeout: 30 * time.Second}))
 }
 
 // WithReaderSource adds entries from a reader to the macpack register.
@@ -54,17 +45,25 @@ func WithRemoteSource(path string) Option {
 // layout:
 // - Registry,Assignment,Organization Name,Organization Address
 // - MA-L, MA-S,AAAAAAAAA, orga1, A street Moscow RU 1234
+// Wireshark manuf, nmap-mac-prefixes and binary indexes created by WriteIndex
+// are detected and accepted as well, each of them optionally gzip compressed.
+// The content of the reader is kept, so the option can be applied again,
+// e.g. by DB.Reload.
 func WithReaderSource(r io.Reader) Option {
-	return func(m MacPack) error {
-		mp, err := newMacPack(r)
+	var (
+		once sync.Once
+		data []byte
+		err  error
+	)
+	return source(func(m *MacPack) error {
+		once.Do(func() {
+			data, err = io.ReadAll(r)
+		})
 		if err != nil {
 			return err
 		}
-		for k, v := range mp {
-			m[k] = v
-		}
-		return nil
-	}
+		return m.load(bytes.NewReader(data), "reader")
+	})
 }
 
 // WithLocalSource adds entries from a local location to the macpack register.
@@ -72,80 +71,525 @@ func WithReaderSource(r io.Reader) Option {
 // The csv source should be formatted represent the following layout:
 // - Registry,Assignment,Organization Name,Organization Address
 // - MA-L, MA-S,AAAAAAAAA, orga1, A street Moscow RU 1234
+// Wireshark manuf, nmap-mac-prefixes and binary indexes created by WriteIndex
+// are detected and accepted as well, each of them optionally gzip compressed.
 func WithLocalSource(path string) Option {
-	return func(m MacPack) error {
+	return source(func(m *MacPack) error {
 		f, err := os.Open(path)
 		if err != nil {
 			return err
 		}
 		defer f.Close()
-		mp, err := newMacPack(f)
-		if err != nil {
+		if err := m.load(f, path); err != nil {
 			return err
 		}
-		for k, v := range mp {
-			m[k] = v
+		m.files = append(m.files, path)
+		return nil
+	})
+}
+
+// WithFSSource adds entries from the named file of a file system to the
+// macpack register, e.g. from the embedded tables.
+// The csv source should be formatted represent the following layout:
+// - Registry,Assignment,Organization Name,Organization Address
+// - MA-L, MA-S,AAAAAAAAA, orga1, A street Moscow RU 1234
+// Wireshark manuf, nmap-mac-prefixes and binary indexes created by WriteIndex
+// are detected and accepted as well, each of them optionally gzip compressed.
+func WithFSSource(fsys fs.FS, name string) Option {
+	return source(func(m *MacPack) error {
+		f, err := fsys.Open(name)
+		if err != nil {
+			return err
 		}
+		defer f.Close()
+		return m.load(f, name)
+	})
+}
+
+// source defers loading until all options have been applied, so options
+// like WithPrecedence affect every source regardless of their position.
+func source(load func(m *MacPack) error) Option {
+	return func(m *MacPack) error {
+		m.loaders = append(m.loaders, load)
 		return nil
 	}
 }
 
-// Organization contains name and address of organization
+// Registry identifies the IEEE registry an assignment was allocated from.
+type Registry string
+
+// Registries published by the IEEE registration authority.
+const (
+	RegistryMAL Registry = "MA-L"
+	RegistryMAM Registry = "MA-M"
+	RegistryMAS Registry = "MA-S"
+	RegistryCID Registry = "CID"
+	RegistryIAB Registry = "IAB"
+	// RegistryWellKnown marks ranges of the table of well-known addresses.
+	// They describe the use of a range and do not replace its vendor.
+	RegistryWellKnown Registry = "Well-Known"
+)
+
+// registryOf returns the registry that assigns blocks of the given length.
+// Since IAB blocks have the same length as MA-S blocks, MA-S is returned for
+// them.
+func registryOf(bits int) Registry {
+	switch bits {
+	case 24:
+		return RegistryMAL
+	case 28:
+		return RegistryMAM
+	case 36:
+		return RegistryMAS
+	}
+	return ""
+}
+
+// Bits returns the prefix length of the blocks assigned by the registry.
+// For unknown registries 0 is returned.
+func (r Registry) Bits() int {
+	switch r {
+	case RegistryMAL, RegistryCID:
+		return 24
+	case RegistryMAM:
+		return 28
+	case RegistryMAS, RegistryIAB:
+		return 36
+	}
+	return 0
+}
+
+// Organization contains name and address of organization. Some sources
+// like the Wireshark manuf file additionally provide a short name. Country
+// and PostalCode are derived from the address, Country holds the ISO 3166
+// alpha-2 code.
 type Organization struct {
-	Name    string
-	Address string
+	Name       string
+	ShortName  string
+	Address    string
+	Country    string
+	PostalCode string
+}
+
+// Prefix is the leading part of a hardware address. Addr holds the 48 bit
+// address with all bits beyond Bits set to zero.
+type Prefix struct {
+	Addr uint64
+	Bits int
+}
+
+// ParsePrefix parses the leading hex digits of a hardware address. Upper and
+// lower case are accepted as well as the following notations:
+// - FF:FF:FF:FF:FF:FF, FF-FF-FF-FF-FF-FF, FFFF.FFFF.FFFF or ffffffffffff
+// Each digit adds 4 bits to the prefix, e.g. "70B3D5" results in 24 bits.
+// The length can be set explicitly with a mask suffix, e.g.
+// "00:1B:C5:00:00:00/36" or "01:00:5E/25".
+func ParsePrefix(s string) (Prefix, error) {
+	digits, mask, hasMask := strings.Cut(s, "/")
+	p, err := parseDigits(digits)
+	if err != nil || !hasMask {
+		return p, err
+	}
+	n, err := strconv.Atoi(mask)
+	if err != nil || n <= 0 || n > p.Bits {
+		return Prefix{}, fmt.Errorf("invalid mask in address %q", s)
+	}
+	p.Bits = n
+	p.Addr &^= 1<<(addrBits-n) - 1
+	return p, nil
+}
+
+func parseDigits(s string) (Prefix, error) {
+	var p Prefix
+	for i := 0; i < len(s); i++ {
+		var d byte
+		switch c := s[i]; {
+		case c == ':' || c == '-' || c == '.':
+			continue
+		case '0' <= c && c <= '9':
+			d = c - '0'
+		case 'a' <= c && c <= 'f':
+			d = c - 'a' + 10
+		case 'A' <= c && c <= 'F':
+			d = c - 'A' + 10
+		default:
+			return Prefix{}, fmt.Errorf("invalid character %q in address %q", c, s)
+		}
+		if p.Bits == addrBits {
+			return Prefix{}, fmt.Errorf("address %q is too long", s)
+		}
+		p.Addr |= uint64(d) << (addrBits - 4 - p.Bits)
+		p.Bits += 4
+	}
+	if p.Bits == 0 {
+		return Prefix{}, fmt.Errorf("empty address %q", s)
+	}
+	return p, nil
+}
+
+// String returns the lower case hex digits covered by the prefix,
+// e.g. 70b3d5f2f. If the length is not a multiple of 4, the mask is appended,
+// e.g. 01005e8/25.
+func (p Prefix) String() string {
+	const digits = "0123456789abcdef"
+	b := make([]byte, (p.Bits+3)/4, (p.Bits+3)/4+3)
+	for i := range b {
+		b[i] = digits[(p.Addr>>(addrBits-4-4*i))&0xf]
+	}
+	if p.Bits%4 != 0 {
+		b = append(b, '/')
+		b = strconv.AppendInt(b, int64(p.Bits), 10)
+	}
+	return string(b)
+}
+
+func (p Prefix) key() (uint64, uint8) {
+	return p.Addr << (64 - addrBits), uint8(p.Bits)
+}
+
+// Kind tells what an assignment is used for.
+type Kind uint8
+
+// Kinds of assignments, all IEEE assignments are vendor blocks. The other
+// kinds are used by the table of well-known ranges.
+const (
+	KindVendor Kind = iota
+	KindVirtualNIC
+	KindMulticast
+	KindProtocol
+	KindVirtualRouter
+)
+
+var kindNames = []string{
+	KindVendor:        "vendor",
+	KindVirtualNIC:    "virtual-nic",
+	KindMulticast:     "multicast",
+	KindProtocol:      "protocol",
+	KindVirtualRouter: "virtual-router",
+}
+
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return "unknown"
+}
+
+// ParseKind returns the kind for its name, e.g. "virtual-nic".
+func ParseKind(s string) (Kind, error) {
+	for k, name := range kindNames {
+		if name == s {
+			return Kind(k), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown kind %q", s)
+}
+
+// Assignment is a block of hardware addresses that has been assigned to an
+// organization. Source names the location the entry was loaded from.
+type Assignment struct {
+	Prefix   Prefix
+	Registry Registry
+	Source   string
+	Kind     Kind
+	Organization
 }
 
 // MacPack is used to store relations between the vendor part of a physical
-// hardware address and the organization behind it.
-type MacPack map[string]Organization
+// hardware address and the organization behind it. The assignments are kept
+// in a bit level prefix trie keyed by the numeric address, organizations,
+// registries and sources are interned, so repeated names are stored once.
+// A MacPack must not be modified after creation, lookups are safe for
+// concurrent use.
+type MacPack struct {
+	trie    trie
+	records []record
+	orgs    []Organization
+	orgIdx  map[Organization]uint32
+	strs    []string
+	strIdx  map[string]uint16
+	sources []SourceInfo
+	// known holds the well-known ranges apart from the vendor blocks, so
+	// they do not replace the assignment of a vendor.
+	known *MacPack
+
+	precedence Precedence
+	conflicts  []conflict
+	parsing    parsing
+	loaders    []func(m *MacPack) error
+	// files are the paths of the local sources, DB watches them for changes.
+	files []string
+}
+
+type record struct {
+	key      uint64
+	org      uint32
+	registry uint16
+	source   uint16
+	bits     uint8
+	kind     Kind
+}
+
+const addrBits = 48
 
 // New returns a new MacPack which can be modified with options.
 // If an error occurs, it will be returned.
-func New(opts ...Option) (MacPack, error) {
-	m := make(MacPack)
+func New(opts ...Option) (*MacPack, error) {
+	m := &MacPack{trie: newTrie()}
 	for _, o := range opts {
 		if err := o(m); err != nil {
 			return nil, err
 		}
 	}
+	for _, load := range m.loaders {
+		if err := load(m); err != nil {
+			return nil, err
+		}
+	}
+	m.loaders = nil
 	return m, nil
 }
 
-// Get returns the organization that belongs to the given address.
+// Len returns the number of assignments, well-known ranges are not counted.
+func (m *MacPack) Len() int {
+	return len(m.records)
+}
+
+// Sources returns the loaded sources in the order they were applied.
+func (m *MacPack) Sources() []SourceInfo {
+	return append([]SourceInfo(nil), m.sources...)
+}
+
+// Get returns the longest assignment that matches the given address.
+// Since MA-M and MA-S blocks are carved out of MA-L blocks, which are often
+// held by the "IEEE Registration Authority", the most specific block wins.
+// If no vendor block covers the address, the well-known range is returned.
 // If there is no entry for the address nil will be returned.
-// Upper and lower case are accepted as well as the following notations:
-// - FF:FF:FF:FF:FF:FF or ffffffffffff
-func (m MacPack) Get(addr string) *Organization {
-	addr = strings.ReplaceAll(addr, ":", "")
-	addr = strings.ToLower(addr)
-	for ; len(addr) > 0; addr = addr[:len(addr)-1] {
-		if o, ok := m[addr]; ok {
-			return &o
-		}
+// All notations accepted by ParsePrefix can be used.
+func (m *MacPack) Get(addr string) *Assignment {
+	p, err := ParsePrefix(addr)
+	if err != nil {
+		return nil
 	}
-	return nil
+	a, ok := m.Lookup(p)
+	if !ok {
+		return nil
+	}
+	return &a
 }
 
-func newMacPack(r io.Reader) (MacPack, error) {
-	reader := csv.NewReader(r)
-	if _, err := reader.Read(); err != nil {
-		return nil, err
+// Lookup returns the longest assignment that covers the given prefix, see
+// Get.
+func (m *MacPack) Lookup(p Prefix) (Assignment, bool) {
+	key, n := p.key()
+	idx, ok := m.trie.lookup(key, n)
+	if !ok {
+		return m.LookupWellKnown(p)
 	}
-	records, err := reader.ReadAll()
-	if err != nil {
-		return nil, err
+	return assignment(m.records[idx], m.orgs, m.strs), true
+}
+
+// LookupWellKnown returns the longest well-known range that covers the
+// given prefix, e.g. "VMware guest" for a block of VMware.
+func (m *MacPack) LookupWellKnown(p Prefix) (Assignment, bool) {
+	if m.known == nil {
+		return Assignment{}, false
 	}
-	m := make(MacPack)
-	for _, rec := range records {
-		if len(rec) < columnBound {
-			// TODO: logger.Warn(decoding, to_short)
+	return m.known.Lookup(p)
+}
+
+// WellKnown returns the well-known ranges ordered by prefix.
+func (m *MacPack) WellKnown() []Assignment {
+	if m.known == nil {
+		return []Assignment{}
+	}
+	return m.known.Assignments()
+}
+
+// Assignments returns all assignments ordered by prefix, well-known ranges
+// are not included.
+func (m *MacPack) Assignments() []Assignment {
+	recs := m.sortedRecords()
+	as := make([]Assignment, 0, len(recs))
+	for _, r := range recs {
+		as = append(as, assignment(r, m.orgs, m.strs))
+	}
+	return as
+}
+
+// sortedRecords returns a copy of the records ordered by prefix.
+func (m *MacPack) sortedRecords() []record {
+	recs := make([]record, len(m.records))
+	copy(recs, m.records)
+	sort.Slice(recs, func(i, j int) bool {
+		if recs[i].key != recs[j].key {
+			return recs[i].key < recs[j].key
+		}
+		return recs[i].bits < recs[j].bits
+	})
+	return recs
+}
+
+func assignment(r record, orgs []Organization, strs []string) Assignment {
+	return Assignment{
+		Prefix:       Prefix{Addr: r.key >> (64 - addrBits), Bits: int(r.bits)},
+		Registry:     Registry(strs[r.registry]),
+		Source:       strs[r.source],
+		Kind:         r.kind,
+		Organization: orgs[r.org],
+	}
+}
+
+// add inserts the assignments, an existing assignment with the same prefix
+// is replaced.
+func (m *MacPack) add(as ...Assignment) {
+	for _, a := range as {
+		key, n := a.Prefix.key()
+		r := record{
+			key:      key,
+			bits:     n,
+			org:      m.internOrg(a.Organization),
+			registry: m.intern(string(a.Registry)),
+			source:   m.intern(a.Source),
+			kind:     a.Kind,
+		}
+		if idx, ok := m.trie.get(key, n); ok {
+			m.merge(idx, r)
 			continue
 		}
-		m[strings.ToLower(rec[columnAssignment])] = Organization{
-			Name:    rec[columnName],
-			Address: rec[columnAddress],
+		m.trie.insert(key, n, uint32(len(m.records)))
+		m.records = append(m.records, r)
+	}
+}
+
+// addIndex inserts the assignments of a binary index. An empty MacPack
+// adopts the tables of the index, which avoids interning every entry again.
+func (m *MacPack) addIndex(ix *index) {
+	if len(m.records) > 0 {
+		as := make([]Assignment, 0, len(ix.records))
+		for _, r := range ix.records {
+			as = append(as, assignment(r, ix.orgs, ix.strs))
 		}
+		m.add(as...)
+		return
 	}
-	return m, nil
+	m.orgs, m.orgIdx = ix.orgs, nil
+	m.strs, m.strIdx = ix.strs, nil
+	m.records = ix.records
+	m.trie.nodes = make([]node, 1, 2*len(ix.records))
+	for i, r := range m.records {
+		m.trie.insert(r.key, r.bits, uint32(i))
+	}
+}
+
+func (m *MacPack) internOrg(o Organization) uint32 {
+	if m.orgIdx == nil {
+		m.orgIdx = make(map[Organization]uint32, len(m.orgs))
+		for i, o := range m.orgs {
+			m.orgIdx[o] = uint32(i)
+		}
+	}
+	if i, ok := m.orgIdx[o]; ok {
+		return i
+	}
+	i := uint32(len(m.orgs))
+	m.orgs = append(m.orgs, o)
+	m.orgIdx[o] = i
+	return i
+}
+
+func (m *MacPack) intern(s string) uint16 {
+	if m.strIdx == nil {
+		m.strIdx = make(map[string]uint16, len(m.strs))
+		for i, s := range m.strs {
+			m.strIdx[s] = uint16(i)
+		}
+	}
+	if i, ok := m.strIdx[s]; ok {
+		return i
+	}
+	i := uint16(len(m.strs))
+	m.strs = append(m.strs, s)
+	m.strIdx[s] = i
+	return i
+}
+
+// load detects the format of the local source and adds its assignments.
+func (m *MacPack) load(r io.Reader, source string) error {
+	return m.loadInfo(r, SourceInfo{Name: source, Origin: OriginLocal})
+}
+
+// loadInfo detects compression and format of the source and adds its
+// assignments. The source is only recorded if it has been loaded successfully.
+// A download without any assignment is an error, it is more likely a captive
+// portal or an error page than an empty table.
+func (m *MacPack) loadInfo(r io.Reader, info SourceInfo) error {
+	source := info.Name
+	br := bufio.NewReaderSize(r, detectSize)
+	if isGzip(br) {
+		zr, err := gzip.NewReader(br)
+		if err != nil {
+			return err
+		}
+		defer zr.Close()
+		br = bufio.NewReaderSize(zr, detectSize)
+	}
+	var (
+		as  []Assignment
+		err error
+	)
+	switch detect(br) {
+	case formatIndex:
+		ix, err := parseIndex(br)
+		if err != nil {
+			return err
+		}
+		if len(ix.records) == 0 && info.Origin == OriginNetwork {
+			return fmt.Errorf("%s: no assignments", source)
+		}
+		m.addIndex(ix)
+		m.sources = append(m.sources, info)
+		return nil
+	case formatManuf:
+		as, err = parseManuf(br, source)
+	case formatNmap:
+		as, err = parseNmap(br, source)
+	case formatWellKnown:
+		if as, err = parseWellKnown(br, source); err != nil {
+			return err
+		}
+		if len(as) == 0 && info.Origin == OriginNetwork {
+			return fmt.Errorf("%s: no assignments", source)
+		}
+		if m.known == nil {
+			m.known = &MacPack{trie: newTrie(), precedence: m.precedence}
+		}
+		m.known.add(as...)
+		m.sources = append(m.sources, info)
+		return nil
+	default:
+		as, err = parseCSV(br, source, m.parsing)
+	}
+	if err != nil {
+		return err
+	}
+	if len(as) == 0 && info.Origin == OriginNetwork {
+		return fmt.Errorf("%s: no assignments", source)
+	}
+	m.add(as...)
+	m.sources = append(m.sources, info)
+	return nil
+}
+
+// WriteCSV writes the assignments ordered by prefix in the layout of the
+// IEEE tables, so the output can be loaded by any of the source options.
+// Short names, kinds and sources are not part of the layout and get lost.
+func (m *MacPack) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"Registry", "Assignment", "Organization Name", "Organization Address"})
+	for _, a := range m.Assignments() {
+		cw.Write([]string{string(a.Registry), strings.ToUpper(a.Prefix.String()), a.Name, a.Address})
+	}
+	cw.Flush()
+	return cw.Error()
 }
@@ -2,35 +2,48 @@ package macpack
 
 import (
 	"bytes"
+	"compress/gzip"
 	"errors"
 	"io"
+	"math/rand"
+	"strings"
 	"testing"
 
+	"github.com/frzifus/vlookup/pkg/tables"
 	"github.com/google/go-cmp/cmp"
 )
 
 var errFailingSource = errors.New("failed")
 
 func withFailingSource() Option {
-	return func(MacPack) error {
+	return func(*MacPack) error {
 		return errFailingSource
 	}
 }
 
 func withTestEntries(entries map[string]Organization) Option {
-	return func(m MacPack) error {
+	return func(m *MacPack) error {
 		for k, v := range entries {
-			m[k] = v
+			m.add(testAssignment(k, RegistryMAL, v))
 		}
 		return nil
 	}
 }
 
+func testAssignment(prefix string, r Registry, o Organization) Assignment {
+	p, err := ParsePrefix(prefix)
+	if err != nil {
+		panic(err)
+	}
+	o = newOrganization(o.Name, o.ShortName, o.Address)
+	return Assignment{Prefix: p, Registry: r, Source: "test", Organization: o}
+}
+
 func TestNew(t *testing.T) {
 	tt := []struct {
 		name    string
 		opts    []Option
-		want    MacPack
+		want    []Assignment
 		wantErr bool
 	}{
 		{
@@ -38,11 +51,27 @@ func TestNew(t *testing.T) {
 			opts: []Option{
 				withTestEntries(
 					map[string]Organization{
-						"test": Organization{Name: "test-name", Address: "East"},
+						"aabbcc": Organization{Name: "test-name", Address: "East"},
 					},
 				)},
-			want: MacPack{
-				"test": Organization{Name: "test-name", Address: "East"},
+			want: []Assignment{
+				testAssignment("aabbcc", RegistryMAL, Organization{Name: "test-name", Address: "East"}),
+			},
+		},
+		{
+			name: "later source overrides",
+			opts: []Option{
+				withTestEntries(map[string]Organization{
+					"aabbcc": Organization{Name: "first"},
+					"aabbcd": Organization{Name: "other"},
+				}),
+				withTestEntries(map[string]Organization{
+					"aabbcc": Organization{Name: "second"},
+				}),
+			},
+			want: []Assignment{
+				testAssignment("aabbcc", RegistryMAL, Organization{Name: "second"}),
+				testAssignment("aabbcd", RegistryMAL, Organization{Name: "other"}),
 			},
 		},
 		{
@@ -53,7 +82,7 @@ func TestNew(t *testing.T) {
 		{
 			name: "empty macpack",
 			opts: []Option{},
-			want: MacPack{},
+			want: []Assignment{},
 		},
 	}
 	for _, tc := range tt {
@@ -63,72 +92,148 @@ func TestNew(t *testing.T) {
 				t.Errorf("New() error = %v, wantErr %v", err, tc.wantErr)
 				return
 			}
-			if !cmp.Equal(got, tc.want) {
-				t.Error(cmp.Diff(got, tc.want))
+			if err != nil {
+				return
+			}
+			if got.Len() != len(tc.want) {
+				t.Errorf("Len() = %d, want %d", got.Len(), len(tc.want))
+			}
+			if !cmp.Equal(got.Assignments(), tc.want) {
+				t.Error(cmp.Diff(got.Assignments(), tc.want))
 			}
 		})
 	}
 }
 
 func TestMacPack_Get(t *testing.T) {
+	m, err := New(func(m *MacPack) error {
+		m.add(
+			testAssignment("ffffff", RegistryMAL, Organization{Name: "test-name1", Address: "East"}),
+			testAssignment("aabbcc", RegistryMAL, Organization{Name: "test-name2", Address: "nord"}),
+			testAssignment("70b3d5", RegistryMAL, Organization{Name: "IEEE Registration Authority"}),
+			testAssignment("70b3d5f2f", RegistryMAS, Organization{Name: "TELEPLATFORMS"}),
+			testAssignment("9806370", RegistryMAM, Organization{Name: "test-name3"}),
+			testAssignment("980637", RegistryMAL, Organization{Name: "IEEE Registration Authority"}),
+		)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
 	tt := []struct {
 		name string
-		m    MacPack
 		addr string
-		want *Organization
+		want *Assignment
 	}{
 		{
 			name: "expected",
-			m: MacPack{
-				"ffffff": Organization{Name: "test-name1", Address: "East"},
-				"aabbcc": Organization{Name: "test-name2", Address: "nord"},
-			},
 			addr: "ffffff",
-			want: &Organization{Name: "test-name1", Address: "East"},
+			want: &Assignment{Prefix: Prefix{Addr: 0xffffff000000, Bits: 24}, Registry: RegistryMAL, Source: "test",
+				Organization: Organization{Name: "test-name1", Address: "East"}},
 		},
 		{
 			name: "cutted",
-			m: MacPack{
-				"ffffff": Organization{Name: "test-name1", Address: "East"},
-				"aabbcc": Organization{Name: "test-name2", Address: "nord"},
-			},
 			addr: "aabbccdd",
-			want: &Organization{Name: "test-name2", Address: "nord"},
+			want: &Assignment{Prefix: Prefix{Addr: 0xaabbcc000000, Bits: 24}, Registry: RegistryMAL, Source: "test",
+				Organization: Organization{Name: "test-name2", Address: "nord"}},
 		},
 		{
 			name: "upper case",
-			m: MacPack{
-				"ffffff": Organization{Name: "test-name1", Address: "East"},
-				"aabbcc": Organization{Name: "test-name2", Address: "nord"},
-			},
 			addr: "AABBCC",
-			want: &Organization{Name: "test-name2", Address: "nord"},
+			want: &Assignment{Prefix: Prefix{Addr: 0xaabbcc000000, Bits: 24}, Registry: RegistryMAL, Source: "test",
+				Organization: Organization{Name: "test-name2", Address: "nord"}},
 		},
 		{
 			name: "upper case and cutted",
-			m: MacPack{
-				"ffffff": Organization{Name: "test-name1", Address: "East"},
-				"aabbcc": Organization{Name: "test-name2", Address: "nord"},
-			},
-			addr: "AABBCCDDEEFF",
-			want: &Organization{Name: "test-name2", Address: "nord"},
+			addr: "AA:BB:CC:DD:EE:FF",
+			want: &Assignment{Prefix: Prefix{Addr: 0xaabbcc000000, Bits: 24}, Registry: RegistryMAL, Source: "test",
+				Organization: Organization{Name: "test-name2", Address: "nord"}},
+		},
+		{
+			name: "ma-s beats ma-l",
+			addr: "70-B3-D5-F2-F0-01",
+			want: &Assignment{Prefix: Prefix{Addr: 0x70b3d5f2f000, Bits: 36}, Registry: RegistryMAS, Source: "test",
+				Organization: Organization{Name: "TELEPLATFORMS"}},
+		},
+		{
+			name: "ma-m beats ma-l",
+			addr: "9806.3701.0203",
+			want: &Assignment{Prefix: Prefix{Addr: 0x980637000000, Bits: 28}, Registry: RegistryMAM, Source: "test",
+				Organization: Organization{Name: "test-name3"}},
+		},
+		{
+			name: "fall back to ma-l",
+			addr: "98:06:37:f0:01:02",
+			want: &Assignment{Prefix: Prefix{Addr: 0x980637000000, Bits: 24}, Registry: RegistryMAL, Source: "test",
+				Organization: Organization{Name: "IEEE Registration Authority"}},
+		},
+		{
+			name: "too short",
+			addr: "aabb",
+		},
+		{
+			name: "invalid",
+			addr: "xx:bb:cc:dd:ee:ff",
+		},
+		{
+			name: "not found",
+			addr: "00:11:22:33:44:55",
 		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			if got := tc.m.Get(tc.addr); !cmp.Equal(got, tc.want) {
+			if got := m.Get(tc.addr); !cmp.Equal(got, tc.want) {
 				t.Error(cmp.Diff(got, tc.want))
 			}
 		})
 	}
 }
 
-func Test_newMacPack(t *testing.T) {
+func TestPrefix_String(t *testing.T) {
 	tt := []struct {
-		name    string
-		r       io.Reader
-		want    MacPack
-		wantErr bool
+		in   string
+		want string
+	}{
+		{in: "70B3D5", want: "70b3d5"},
+		{in: "70:B3:D5:F2:F", want: "70b3d5f2f"},
+		{in: "aabb.ccdd.eeff", want: "aabbccddeeff"},
+		{in: "01:00:5E:80/25", want: "01005e8/25"},
+	}
+	for _, tc := range tt {
+		t.Run(tc.in, func(t *testing.T) {
+			p, err := ParsePrefix(tc.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := p.String(); got != tc.want {
+				t.Errorf("String() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+// truncatedGzip returns a reader that decompresses the table, with the header
+// of the IEEE tables prepended, but misses the end of the compressed stream.
+func truncatedGzip(table string) io.Reader {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write([]byte("Registry,Assignment,Organization Name,Organization Address\n" + table))
+	zw.Close()
+	zr, err := gzip.NewReader(bytes.NewReader(buf.Bytes()[:buf.Len()-12]))
+	if err != nil {
+		panic(err)
+	}
+	return zr
+}
+
+func Test_parseCSV(t *testing.T) {
+	tt := []struct {
+		name      string
+		r         io.Reader
+		mode      ParseMode
+		want      []Assignment
+		wantErr   bool
+		wantDiags []string
 	}{
 		{
 			name: "expected",
@@ -137,15 +242,15 @@ func Test_newMacPack(t *testing.T) {
 				 MA-S,70B3D5F2F,TELEPLATFORMS,"Polbina st., 3/1 Moscow  RU 109388"
 				 MA-S,70B3D5719,2M Technology,802 Greenview Drive  Grand Prairie TX US 75050`),
 			),
-			want: MacPack{
-				"70b3d5719": {Name: "2M Technology", Address: "802 Greenview Drive  Grand Prairie TX US 75050"},
-				"70b3d5f2f": {Name: "TELEPLATFORMS", Address: "Polbina st., 3/1 Moscow  RU 109388"},
+			want: []Assignment{
+				testAssignment("70b3d5f2f", RegistryMAS, Organization{Name: "TELEPLATFORMS", Address: "Polbina st., 3/1 Moscow  RU 109388"}),
+				testAssignment("70b3d5719", RegistryMAS, Organization{Name: "2M Technology", Address: "802 Greenview Drive  Grand Prairie TX US 75050"}),
 			},
 		},
 		{
 			name: "empty",
 			r:    bytes.NewBuffer([]byte(`Registry,Assignment,Organization Name,Organization Address`)),
-			want: MacPack{},
+			want: []Assignment{},
 		},
 		{
 			name: "invalid line length",
@@ -156,17 +261,250 @@ func Test_newMacPack(t *testing.T) {
 			),
 			wantErr: true,
 		},
+		{
+			name: "invalid assignment",
+			r: bytes.NewBuffer([]byte(
+				`Registry,Assignment,Organization Name,Organization Address
+				 MA-S,70B3D5F2X,TELEPLATFORMS,"Polbina st., 3/1 Moscow  RU 109388"`),
+			),
+			wantErr: true,
+		},
+		{
+			name: "lenient",
+			r: bytes.NewBuffer([]byte(
+				`Registry,Assignment,Organization Name,Organization Address
+				 MA-S,70B3D5F2F,TELEPLATFORMS,"Polbina st., 3/1 Moscow  RU 109388"
+				 MA-S,70B3D5F2X,Invalid Hex,
+				 MA-S,70B3D5
+				 MA-L,70B3D5719,Wrong Length,
+				 MA-S,70B3D5718,"Bad "Quotes" Inc",Somewhere
+				 MA-S,70B3D5719,2M Technology,802 Greenview Drive  Grand Prairie TX US 75050`),
+			),
+			mode: ParseLenient,
+			want: []Assignment{
+				testAssignment("70b3d5f2f", RegistryMAS, Organization{Name: "TELEPLATFORMS", Address: "Polbina st., 3/1 Moscow  RU 109388"}),
+				testAssignment("70b3d5718", RegistryMAS, Organization{Name: `Bad "Quotes" Inc`, Address: "Somewhere"}),
+				testAssignment("70b3d5719", RegistryMAS, Organization{Name: "2M Technology", Address: "802 Greenview Drive  Grand Prairie TX US 75050"}),
+			},
+			wantDiags: []string{
+				`test: line 3: invalid character 'X' in address "70B3D5F2X"`,
+				"test: line 4: got 2 of 4 columns",
+				"test: line 5: MA-L assignment 70b3d5719 has 36 bits, want 24",
+			},
+		},
+		{
+			name: "short assignment",
+			r: bytes.NewBuffer([]byte(
+				`Registry,Assignment,Organization Name,Organization Address
+				 <p>a,b,c,d</p>
+				 ,00D0EF,IGT,9295 PROTOTYPE DRIVE RENO NV US 89511`),
+			),
+			mode: ParseLenient,
+			want: []Assignment{
+				testAssignment("00d0ef", "", Organization{Name: "IGT", Address: "9295 PROTOTYPE DRIVE RENO NV US 89511"}),
+			},
+			wantDiags: []string{
+				"test: line 2: assignment b has 4 bits, want at least 24",
+			},
+		},
+		{
+			name:    "truncated gzip",
+			r:       truncatedGzip(strings.Repeat("MA-L,00D0EF,IGT,9295 PROTOTYPE DRIVE RENO NV US 89511\n", 100)),
+			mode:    ParseLenient,
+			wantErr: true,
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.name, func(t *testing.T) {
-			got, err := newMacPack(tc.r)
+			var diags []string
+			got, err := parseCSV(tc.r, "test", parsing{mode: tc.mode, diag: func(d Diagnostic) {
+				diags = append(diags, d.Error())
+			}})
 			if (err != nil) != tc.wantErr {
-				t.Errorf("newMacPack() error = %v, wantErr %v", err, tc.wantErr)
+				t.Errorf("parseCSV() error = %v, wantErr %v", err, tc.wantErr)
 				return
 			}
 			if !cmp.Equal(got, tc.want) {
 				t.Error(cmp.Diff(got, tc.want))
 			}
+			if !cmp.Equal(diags, tc.wantDiags) {
+				t.Error(cmp.Diff(diags, tc.wantDiags))
+			}
+		})
+	}
+}
+
+func TestMacPack_WriteCSV(t *testing.T) {
+	mp, err := New(WithReaderSource(strings.NewReader(
+		`# Wireshark manuf
+00:00:0C	Cisco	Cisco Systems, Inc
+00:1B:C5:00:00:00/36	Converging	Converging Systems Inc.
+00:50:C2:00:00:00/40	Example	Example Corp`)), WithReaderSource(strings.NewReader(
+		`Registry,Assignment,Organization Name,Organization Address
+MA-L,00000C,"Cisco Systems, Inc","170 WEST TASMAN DRIVE SAN JOSE CA US 95134"`)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := mp.WriteCSV(&b); err != nil {
+		t.Fatal(err)
+	}
+	want := `Registry,Assignment,Organization Name,Organization Address
+MA-L,00000C,"Cisco Systems, Inc",170 WEST TASMAN DRIVE SAN JOSE CA US 95134
+MA-S,001BC5000,Converging Systems Inc.,
+,0050C20000,Example Corp,
+`
+	if got := b.String(); got != want {
+		t.Error(cmp.Diff(got, want))
+	}
+
+	// the output loads again without losing assignments.
+	again, err := New(WithReaderSource(&b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, want2 := again.Assignments(), mp.Assignments()
+	for i := range want2 {
+		want2[i].Source, want2[i].ShortName = "reader", ""
+	}
+	if !cmp.Equal(got, want2) {
+		t.Error(cmp.Diff(got, want2))
+	}
+}
+
+// mapPack is the former map based implementation, it serves as reference
+// for the benchmarks.
+type mapPack map[string]Assignment
+
+func (m mapPack) Get(addr string) *Assignment {
+	addr = strings.NewReplacer(":", "", "-", "", ".", "").Replace(addr)
+	addr = strings.ToLower(addr)
+	for ; len(addr) > 0; addr = addr[:len(addr)-1] {
+		if a, ok := m[addr]; ok {
+			return &a
+		}
+	}
+	return nil
+}
+
+func TestMacPack_GetMatchesMap(t *testing.T) {
+	m, err := New(benchmarkSources()...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ref := make(mapPack)
+	for _, a := range m.Assignments() {
+		ref[a.Prefix.String()] = a
+	}
+	if len(ref) != m.Len() {
+		t.Fatalf("got %d unique prefixes, want %d", len(ref), m.Len())
+	}
+	for _, addr := range randomAddrs(m, 10000) {
+		if got, want := m.Get(addr), ref.Get(addr); !cmp.Equal(got, want) {
+			t.Fatalf("%s: %s", addr, cmp.Diff(got, want))
+		}
+	}
+}
+
+// TestEmbeddedTables ensures the compressed indexes match the csv files they
+// are generated from.
+func TestEmbeddedTables(t *testing.T) {
+	for name, csv := range map[string]string{
+		tables.MACLarge:  "../tables/oui.csv",
+		tables.MACMedium: "../tables/mam.csv",
+		tables.MACSmall:  "../tables/oui36.csv",
+	} {
+		t.Run(name, func(t *testing.T) {
+			embedded, err := New(WithFSSource(tables.Get(), name))
+			if err != nil {
+				t.Fatal(err)
+			}
+			m, err := New(WithLocalSource(csv))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, want := embedded.Assignments(), m.Assignments()
+			for i := range got {
+				got[i].Source = ""
+			}
+			for i := range want {
+				want[i].Source = ""
+			}
+			if !cmp.Equal(got, want) {
+				t.Errorf("%s is outdated, run make generate: %s", name, cmp.Diff(got, want))
+			}
 		})
 	}
 }
+
+func benchmarkSources() []Option {
+	fs := tables.Get()
+	return []Option{
+		WithFSSource(fs, tables.MACLarge),
+		WithFSSource(fs, tables.MACMedium),
+		WithFSSource(fs, tables.MACSmall),
+	}
+}
+
+func randomAddrs(m *MacPack, n int) []string {
+	r := rand.New(rand.NewSource(1))
+	as := m.Assignments()
+	addrs := make([]string, n)
+	for i := range addrs {
+		p := as[r.Intn(len(as))].Prefix
+		addr := p.Addr | uint64(r.Int63())&(1<<(addrBits-p.Bits)-1)
+		addrs[i] = Prefix{Addr: addr, Bits: addrBits}.String()
+	}
+	return addrs
+}
+
+func BenchmarkNew(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := New(benchmarkSources()...); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGet(b *testing.B) {
+	m, err := New(benchmarkSources()...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	addrs := randomAddrs(m, 1024)
+	ref := make(mapPack)
+	for _, a := range m.Assignments() {
+		ref[a.Prefix.String()] = a
+	}
+
+	b.Run("trie", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if m.Get(addrs[i%len(addrs)]) == nil {
+				b.Fatal("not found")
+			}
+		}
+	})
+	b.Run("trie-lookup", func(b *testing.B) {
+		ps := make([]Prefix, len(addrs))
+		for i, a := range addrs {
+			ps[i], _ = ParsePrefix(a)
+		}
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if _, ok := m.Lookup(ps[i%len(ps)]); !ok {
+				b.Fatal("not found")
+			}
+		}
+	})
+	b.Run("map", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			if ref.Get(addrs[i%len(addrs)]) == nil {
+				b.Fatal("not found")
+			}
+		}
+	})
+}
@@ -0,0 +1,85 @@
+package macpack
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Precedence decides which source wins if several sources define the same
+// prefix.
+type Precedence uint8
+
+const (
+	// PrecedenceLast lets later sources override earlier ones, e.g. to
+	// layer an internal table over the IEEE tables. This is the default.
+	PrecedenceLast Precedence = iota
+	// PrecedenceFirst keeps the definition of the first source, later
+	// sources only add prefixes that are still missing.
+	PrecedenceFirst
+)
+
+// ParsePrecedence parses the names "last" and "first".
+func ParsePrecedence(s string) (Precedence, error) {
+	switch s {
+	case "last":
+		return PrecedenceLast, nil
+	case "first":
+		return PrecedenceFirst, nil
+	}
+	return 0, fmt.Errorf("unknown precedence %q", s)
+}
+
+// WithPrecedence sets how sources are merged. It applies to all sources,
+// independent of the position of the option.
+func WithPrecedence(p Precedence) Option {
+	return func(m *MacPack) error {
+		m.precedence = p
+		return nil
+	}
+}
+
+// Conflict of two sources that define the same prefix differently, i.e.
+// with another organization, registry or kind.
+type Conflict struct {
+	// Kept is the assignment in use.
+	Kept Assignment
+	// Shadowed is the assignment that lost, its Source tells where it was
+	// defined.
+	Shadowed Assignment
+}
+
+type conflict struct {
+	kept, shadowed record
+}
+
+// Conflicts returns the conflicts that occurred while merging the sources,
+// ordered by prefix. If three sources define the same prefix, each layer is
+// reported separately.
+func (m *MacPack) Conflicts() []Conflict {
+	cs := make([]Conflict, 0, len(m.conflicts))
+	for _, c := range m.conflicts {
+		cs = append(cs, Conflict{
+			Kept:     assignment(c.kept, m.orgs, m.strs),
+			Shadowed: assignment(c.shadowed, m.orgs, m.strs),
+		})
+	}
+	sort.SliceStable(cs, func(i, j int) bool {
+		return less(cs[i].Kept.Prefix, cs[j].Kept.Prefix)
+	})
+	return cs
+}
+
+// merge resolves a record for a prefix that is already stored at idx.
+func (m *MacPack) merge(idx uint32, r record) {
+	old := m.records[idx]
+	if old.org != r.org || old.registry != r.registry || old.kind != r.kind {
+		c := conflict{kept: r, shadowed: old}
+		if m.precedence == PrecedenceFirst {
+			c = conflict{kept: old, shadowed: r}
+		}
+		m.conflicts = append(m.conflicts, c)
+	}
+	if m.precedence == PrecedenceLast {
+		m.records[idx] = r
+	}
+}
@@ -0,0 +1,72 @@
+package macpack
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestMacPack_Conflicts(t *testing.T) {
+	const (
+		ieee = `Registry,Assignment,Organization Name,Organization Address
+MA-L,00D0EF,IGT,9295 PROTOTYPE DRIVE RENO NV US 89511
+MA-L,F4BD9E,"Cisco Systems, Inc",80 West Tasman Drive San Jose CA US 94568`
+		internal = `Registry,Assignment,Organization Name,Organization Address
+MA-L,F4BD9E,Lab Switches,
+MA-L,00D0EF,IGT,9295 PROTOTYPE DRIVE RENO NV US 89511
+MA-S,70B3D5719,2M Technology,802 Greenview Drive  Grand Prairie TX US 75050`
+	)
+	cisco := testAssignment("f4bd9e", RegistryMAL, Organization{Name: "Cisco Systems, Inc", Address: "80 West Tasman Drive San Jose CA US 94568"})
+	cisco.Source = "ieee"
+	lab := testAssignment("f4bd9e", RegistryMAL, Organization{Name: "Lab Switches"})
+	lab.Source = "internal"
+
+	tests := []struct {
+		name       string
+		precedence Precedence
+		want       string
+		conflicts  []Conflict
+	}{
+		{
+			name:       "last",
+			precedence: PrecedenceLast,
+			want:       "Lab Switches",
+			conflicts:  []Conflict{{Kept: lab, Shadowed: cisco}},
+		},
+		{
+			name:       "first",
+			precedence: PrecedenceFirst,
+			want:       "Cisco Systems, Inc",
+			conflicts:  []Conflict{{Kept: cisco, Shadowed: lab}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the precedence applies even if it is given after the sources.
+			mp, err := New(
+				withNamedSource(ieee, "ieee"),
+				withNamedSource(internal, "internal"),
+				WithPrecedence(tt.precedence),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := mp.Get("f4:bd:9e:01:02:03"); got == nil || got.Name != tt.want {
+				t.Errorf("Get() = %+v, want %s", got, tt.want)
+			}
+			if mp.Len() != 3 {
+				t.Errorf("Len() = %d, want 3", mp.Len())
+			}
+			if got := mp.Conflicts(); !cmp.Equal(got, tt.conflicts) {
+				t.Error(cmp.Diff(got, tt.conflicts))
+			}
+		})
+	}
+}
+
+func withNamedSource(data, name string) Option {
+	return source(func(m *MacPack) error {
+		return m.load(strings.NewReader(data), name)
+	})
+}
@@ -0,0 +1,135 @@
+package macpack
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ParseMode decides how malformed rows of csv sources are handled.
+type ParseMode uint8
+
+const (
+	// ParseStrict fails the whole source on the first malformed row. This
+	// is the default.
+	ParseStrict ParseMode = iota
+	// ParseLenient skips malformed rows and reports them as Diagnostic.
+	// Quotes inside of fields are accepted as well.
+	ParseLenient
+)
+
+// WithParseMode sets how malformed rows of csv sources are handled.
+func WithParseMode(mode ParseMode) Option {
+	return func(m *MacPack) error {
+		m.parsing.mode = mode
+		return nil
+	}
+}
+
+// WithDiagnostics sets a function that is called for every row skipped in
+// lenient mode.
+func WithDiagnostics(fn func(Diagnostic)) Option {
+	return func(m *MacPack) error {
+		m.parsing.diag = fn
+		return nil
+	}
+}
+
+// Diagnostic describes a malformed row of a source. In strict mode it is
+// returned as error.
+type Diagnostic struct {
+	Source string
+	Line   int
+	Err    error
+}
+
+func (d Diagnostic) Error() string {
+	return fmt.Sprintf("%s: line %d: %v", d.Source, d.Line, d.Err)
+}
+
+func (d Diagnostic) Unwrap() error {
+	return d.Err
+}
+
+type parsing struct {
+	mode ParseMode
+	diag func(Diagnostic)
+}
+
+// parseCSV reads the IEEE layout row by row. Rows need all columns, a valid
+// assignment and, for the known registries, an assignment of the length
+// the registry hands out.
+func parseCSV(r io.Reader, source string, p parsing) ([]Assignment, error) {
+	reader := csv.NewReader(r)
+	reader.ReuseRecord = true
+	if p.mode == ParseLenient {
+		reader.FieldsPerRecord = -1
+		reader.LazyQuotes = true
+	}
+	if _, err := reader.Read(); err != nil {
+		return nil, err
+	}
+	var as []Assignment
+	for {
+		rec, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		var pe *csv.ParseError
+		if err != nil && !errors.As(err, &pe) {
+			// the source itself failed, e.g. a truncated download, there is
+			// no row left that could be skipped.
+			return nil, err
+		}
+		var a Assignment
+		if err == nil {
+			a, err = parseRow(rec, source)
+		}
+		if err == nil {
+			as = append(as, a)
+			continue
+		}
+		d := Diagnostic{Source: source, Err: err}
+		if pe != nil {
+			d.Line, d.Err = pe.StartLine, pe.Err
+		} else {
+			d.Line, _ = reader.FieldPos(0)
+		}
+		if p.mode == ParseStrict {
+			return nil, d
+		}
+		if p.diag != nil {
+			p.diag(d)
+		}
+	}
+	if as == nil {
+		as = []Assignment{}
+	}
+	return as, nil
+}
+
+func parseRow(rec []string, source string) (Assignment, error) {
+	if len(rec) < columnBound {
+		return Assignment{}, fmt.Errorf("got %d of %d columns", len(rec), columnBound)
+	}
+	p, err := ParsePrefix(strings.TrimSpace(rec[columnAssignment]))
+	if err != nil {
+		return Assignment{}, err
+	}
+	reg := Registry(strings.TrimSpace(rec[columnRegistry]))
+	switch n := reg.Bits(); {
+	case n != 0 && n != p.Bits:
+		return Assignment{}, fmt.Errorf("%s assignment %s has %d bits, want %d", reg, p, p.Bits, n)
+	case n == 0 && p.Bits < 24:
+		// no registry hands out blocks larger than an MA-L.
+		return Assignment{}, fmt.Errorf("assignment %s has %d bits, want at least 24", p, p.Bits)
+	}
+	return Assignment{
+		Prefix:       p,
+		Registry:     reg,
+		Source:       source,
+		Organization: newOrganization(rec[columnName], "", rec[columnAddress]),
+	}, nil
+}
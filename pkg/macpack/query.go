@@ -0,0 +1,127 @@
+package macpack
+
+import (
+	"net"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Matcher reports whether the name of an organization matches a query.
+type Matcher func(name string) bool
+
+// MatchSubstring returns a Matcher that looks for s in the name, upper and
+// lower case are ignored.
+func MatchSubstring(s string) Matcher {
+	s = strings.ToLower(s)
+	return func(name string) bool {
+		return strings.Contains(strings.ToLower(name), s)
+	}
+}
+
+// MatchRegexp returns a Matcher that matches names against the regular
+// expression expr.
+func MatchRegexp(expr string) (Matcher, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString, nil
+}
+
+// Vendor groups all assignments of organizations with the same name.
+type Vendor struct {
+	Name   string
+	Blocks []Assignment
+}
+
+// Addresses returns the number of addresses of all blocks.
+func (v Vendor) Addresses() uint64 {
+	var n uint64
+	for _, b := range v.Blocks {
+		n += b.Prefix.Size()
+	}
+	return n
+}
+
+// Search returns the vendors whose name matches, ordered by name. The
+// blocks of a vendor are ordered by prefix.
+func (m *MacPack) Search(match Matcher) []Vendor {
+	matched := make(map[uint32]bool)
+	byName := make(map[string]*Vendor)
+	for _, r := range m.sortedRecords() {
+		ok, seen := matched[r.org]
+		if !seen {
+			ok = match(m.orgs[r.org].Name)
+			matched[r.org] = ok
+		}
+		if !ok {
+			continue
+		}
+		a := assignment(r, m.orgs, m.strs)
+		v, found := byName[a.Name]
+		if !found {
+			v = &Vendor{Name: a.Name}
+			byName[a.Name] = v
+		}
+		v.Blocks = append(v.Blocks, a)
+	}
+	vendors := make([]Vendor, 0, len(byName))
+	for _, v := range byName {
+		vendors = append(vendors, *v)
+	}
+	sort.Slice(vendors, func(i, j int) bool {
+		return vendors[i].Name < vendors[j].Name
+	})
+	return vendors
+}
+
+// Stat summarizes the assignments of a registry loaded from a source.
+type Stat struct {
+	Registry  Registry
+	Source    string
+	Blocks    int
+	Addresses uint64
+}
+
+// Stats returns the number of blocks and addresses per source and registry,
+// ordered by source and registry.
+func (m *MacPack) Stats() []Stat {
+	type key struct{ registry, source uint16 }
+	counts := make(map[key]*Stat)
+	for _, r := range m.records {
+		k := key{registry: r.registry, source: r.source}
+		s, ok := counts[k]
+		if !ok {
+			s = &Stat{Registry: Registry(m.strs[r.registry]), Source: m.strs[r.source]}
+			counts[k] = s
+		}
+		s.Blocks++
+		s.Addresses += Prefix{Bits: int(r.bits)}.Size()
+	}
+	stats := make([]Stat, 0, len(counts))
+	for _, s := range counts {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].Source != stats[j].Source {
+			return stats[i].Source < stats[j].Source
+		}
+		return stats[i].Registry < stats[j].Registry
+	})
+	return stats
+}
+
+// Size returns the number of addresses covered by the prefix.
+func (p Prefix) Size() uint64 {
+	return 1 << (addrBits - p.Bits)
+}
+
+// HardwareAddr returns the first address covered by the prefix.
+func (p Prefix) HardwareAddr() net.HardwareAddr {
+	mac := make(net.HardwareAddr, addrBits/8)
+	for i := range mac {
+		mac[i] = byte(p.Addr >> (addrBits - 8 - 8*i))
+	}
+	return mac
+}
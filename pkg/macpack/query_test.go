@@ -0,0 +1,102 @@
+package macpack
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func testQueryPack(t *testing.T) *MacPack {
+	t.Helper()
+	m, err := New(func(m *MacPack) error {
+		m.add(
+			testAssignment("f4bd9e", RegistryMAL, Organization{Name: "Cisco Systems, Inc", Address: "San Jose CA US 94568"}),
+			testAssignment("00000c", RegistryMAL, Organization{Name: "Cisco Systems, Inc", Address: "San Jose CA US 95134"}),
+			testAssignment("70b3d5f2f", RegistryMAS, Organization{Name: "TELEPLATFORMS"}),
+			testAssignment("9806370", RegistryMAM, Organization{Name: "Ciscom"}),
+		)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+func TestMacPack_Search(t *testing.T) {
+	m := testQueryPack(t)
+	re, err := MatchRegexp(`^Cisco Systems`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt := []struct {
+		name  string
+		match Matcher
+		want  map[string][]string
+	}{
+		{
+			name:  "substring",
+			match: MatchSubstring("cisco"),
+			want: map[string][]string{
+				"Cisco Systems, Inc": {"00000c", "f4bd9e"},
+				"Ciscom":             {"9806370"},
+			},
+		},
+		{
+			name:  "regexp",
+			match: re,
+			want: map[string][]string{
+				"Cisco Systems, Inc": {"00000c", "f4bd9e"},
+			},
+		},
+		{
+			name:  "no match",
+			match: MatchSubstring("apple"),
+			want:  map[string][]string{},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got := make(map[string][]string)
+			var names []string
+			for _, v := range m.Search(tc.match) {
+				names = append(names, v.Name)
+				for _, b := range v.Blocks {
+					got[v.Name] = append(got[v.Name], b.Prefix.String())
+				}
+			}
+			if !cmp.Equal(got, tc.want) {
+				t.Error(cmp.Diff(got, tc.want))
+			}
+			for i := 1; i < len(names); i++ {
+				if names[i-1] > names[i] {
+					t.Errorf("vendors not ordered: %v", names)
+				}
+			}
+		})
+	}
+}
+
+func TestMacPack_Stats(t *testing.T) {
+	want := []Stat{
+		{Registry: RegistryMAL, Source: "test", Blocks: 2, Addresses: 2 << 24},
+		{Registry: RegistryMAM, Source: "test", Blocks: 1, Addresses: 1 << 20},
+		{Registry: RegistryMAS, Source: "test", Blocks: 1, Addresses: 1 << 12},
+	}
+	if got := testQueryPack(t).Stats(); !cmp.Equal(got, want) {
+		t.Error(cmp.Diff(got, want))
+	}
+}
+
+func TestPrefix_HardwareAddr(t *testing.T) {
+	p, err := ParsePrefix("70:B3:D5:F2:F")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := p.HardwareAddr().String(), "70:b3:d5:f2:f0:00"; got != want {
+		t.Errorf("HardwareAddr() = %s, want %s", got, want)
+	}
+	if got, want := p.Size(), uint64(4096); got != want {
+		t.Errorf("Size() = %d, want %d", got, want)
+	}
+}
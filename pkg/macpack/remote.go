@@ -1,8 +1,274 @@
 package macpack
 
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+)
+
 // https://regauth.standards.ieee.org/standards-ra-web/pub/view.html#registries
 const (
 	RemoteIeeeMACLarge  string = "http://standards-oui.ieee.org/oui/oui.csv"
 	RemoteIeeeMACMedium string = "http://standards-oui.ieee.org/oui28/mam.csv"
 	RemoteIeeeMACSmall  string = "http://standards-oui.ieee.org/oui36/oui36.csv"
+	RemoteIeeeIAB       string = "http://standards-oui.ieee.org/iab/iab.csv"
+	RemoteIeeeCID       string = "http://standards-oui.ieee.org/cid/cid.csv"
+)
+
+// Vendor databases shipped by other tools. Sources detect their format, so
+// they can be passed to WithRemoteSource as well.
+const (
+	RemoteWiresharkManuf string = "https://www.wireshark.org/download/automated/data/manuf"
+	RemoteNmapPrefixes   string = "https://raw.githubusercontent.com/nmap/nmap/master/nmap-mac-prefixes"
+)
+
+// Origin tells which copy of a source was loaded.
+type Origin string
+
+// Origins of a source.
+const (
+	// OriginLocal is used for files, readers and file systems.
+	OriginLocal Origin = "local"
+	// OriginNetwork is used for remote sources that were downloaded.
+	OriginNetwork Origin = "network"
+	// OriginCache is used for remote sources loaded from the cache, either
+	// because the copy was fresh, has been revalidated or the download failed.
+	OriginCache Origin = "cache"
+	// OriginFallback is used for remote sources that were replaced by their
+	// fallback, because neither the download nor the cache were available.
+	OriginFallback Origin = "fallback"
 )
+
+// SourceInfo describes a loaded source.
+type SourceInfo struct {
+	// Name of the source as used by Assignment.Source.
+	Name   string
+	Origin Origin
+	// Err is the error that caused a remote source to use a cached copy or
+	// its fallback instead of the network.
+	Err error
+}
+
+// A RemoteOption configures a remote source.
+type RemoteOption func(r *remote)
+
+// RemoteClient sets the http client used for the download. By default
+// http.DefaultClient is used.
+func RemoteClient(c *http.Client) RemoteOption {
+	return func(r *remote) {
+		r.client = c
+	}
+}
+
+// RemoteCache stores downloads in dir. A cached copy younger than ttl is used
+// without contacting the server, an older one is revalidated using its ETag
+// and Last-Modified header. If the download fails, the cached copy is used
+// regardless of its age.
+func RemoteCache(dir string, ttl time.Duration) RemoteOption {
+	return func(r *remote) {
+		r.cacheDir = dir
+		r.ttl = ttl
+	}
+}
+
+// RemoteFallback loads the named file of fsys, e.g. the embedded tables, if
+// neither the download nor a cached copy is available.
+func RemoteFallback(fsys fs.FS, name string) RemoteOption {
+	return func(r *remote) {
+		r.fallback = fsys
+		r.fallbackName = name
+	}
+}
+
+// DefaultCacheDir returns the directory used to cache remote sources, which
+// is vlookup inside the user cache directory, e.g. $XDG_CACHE_HOME/vlookup.
+func DefaultCacheDir() (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "vlookup"), nil
+}
+
+// WithRemote adds entries from a remote location to the macpack register.
+// The download is bound to ctx. Without options the source behaves like
+// WithRemoteSource, the options add a cache and a fallback. Which copy was
+// loaded is reported by MacPack.Sources.
+func WithRemote(ctx context.Context, url string, opts ...RemoteOption) Option {
+	r := &remote{url: url, client: http.DefaultClient}
+	for _, o := range opts {
+		o(r)
+	}
+	return source(func(m *MacPack) error {
+		return r.load(ctx, m)
+	})
+}
+
+type remote struct {
+	url          string
+	client       *http.Client
+	cacheDir     string
+	ttl          time.Duration
+	fallback     fs.FS
+	fallbackName string
+}
+
+// cacheEntry is stored next to a cached copy.
+type cacheEntry struct {
+	URL          string    `json:"url"`
+	Time         time.Time `json:"time"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+}
+
+// cachePath returns the path of the cached copy, the metadata is stored with
+// the additional extension .json.
+func (r *remote) cachePath() string {
+	sum := sha256.Sum256([]byte(r.url))
+	return filepath.Join(r.cacheDir, hex.EncodeToString(sum[:8])+"-"+path.Base(r.url))
+}
+
+func (r *remote) readCache() (*cacheEntry, []byte, error) {
+	if r.cacheDir == "" {
+		return nil, nil, errors.New("no cache")
+	}
+	p := r.cachePath()
+	meta, err := os.ReadFile(p + ".json")
+	if err != nil {
+		return nil, nil, err
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(meta, &e); err != nil {
+		return nil, nil, err
+	}
+	if e.URL != r.url {
+		return nil, nil, fmt.Errorf("cache entry of %s belongs to %s", p, e.URL)
+	}
+	data, err := os.ReadFile(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &e, data, nil
+}
+
+func (r *remote) writeCache(e *cacheEntry, data []byte) error {
+	if err := os.MkdirAll(r.cacheDir, 0755); err != nil {
+		return err
+	}
+	p := r.cachePath()
+	if data != nil {
+		if err := writeFile(p, data); err != nil {
+			return err
+		}
+	}
+	meta, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	return writeFile(p+".json", meta)
+}
+
+// writeFile replaces the file atomically.
+func writeFile(name string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(0644)
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), name)
+}
+
+func (r *remote) load(ctx context.Context, m *MacPack) error {
+	cached, data, cacheErr := r.readCache()
+	if cacheErr == nil && time.Since(cached.Time) < r.ttl {
+		return m.loadInfo(bytes.NewReader(data), SourceInfo{Name: r.url, Origin: OriginCache})
+	}
+	err := r.download(ctx, m, cached, data)
+	if err == nil {
+		return nil
+	}
+	if cacheErr == nil {
+		return m.loadInfo(bytes.NewReader(data), SourceInfo{Name: r.url, Origin: OriginCache, Err: err})
+	}
+	if r.fallback != nil {
+		f, ferr := r.fallback.Open(r.fallbackName)
+		if ferr != nil {
+			return fmt.Errorf("%v, fallback: %w", err, ferr)
+		}
+		defer f.Close()
+		return m.loadInfo(f, SourceInfo{Name: r.url, Origin: OriginFallback, Err: err})
+	}
+	return err
+}
+
+// download fetches the source, revalidating the cached copy if there is one.
+// A download is only cached once it has been loaded successfully.
+func (r *remote) download(ctx context.Context, m *MacPack, cached *cacheEntry, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return err
+	}
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		if err := m.loadInfo(bytes.NewReader(data), SourceInfo{Name: r.url, Origin: OriginCache}); err != nil {
+			return err
+		}
+		cached.Time = time.Now()
+		// a failed update only causes another revalidation.
+		r.writeCache(cached, nil)
+		return nil
+	case resp.StatusCode != http.StatusOK:
+		return fmt.Errorf("%s: unexpected status: %s", r.url, resp.Status)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err := m.loadInfo(bytes.NewReader(body), SourceInfo{Name: r.url, Origin: OriginNetwork}); err != nil {
+		return err
+	}
+	if r.cacheDir != "" {
+		// the source is loaded, a cache failure only costs a download.
+		r.writeCache(&cacheEntry{
+			URL:          r.url,
+			Time:         time.Now(),
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		}, body)
+	}
+	return nil
+}
@@ -0,0 +1,126 @@
+package macpack
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testRemoteTable = `Registry,Assignment,Organization Name,Organization Address
+MA-L,00D0EF,IGT,9295 PROTOTYPE DRIVE RENO NV US 89511
+`
+
+// testRemote serves testRemoteTable with an ETag and records the responses.
+// A portal answers every request with a login page, like a captive portal.
+type testRemote struct {
+	mu     sync.Mutex
+	down   bool
+	portal bool
+	status []int
+}
+
+func (s *testRemote) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.portal {
+		s.status = append(s.status, http.StatusOK)
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte("<html><body><p>Please log in, to access the internet.</p></body></html>"))
+		return
+	}
+	code := http.StatusOK
+	switch {
+	case s.down:
+		code = http.StatusBadGateway
+	case r.Header.Get("If-None-Match") == `"v1"`:
+		code = http.StatusNotModified
+	}
+	s.status = append(s.status, code)
+	w.Header().Set("ETag", `"v1"`)
+	w.WriteHeader(code)
+	if code == http.StatusOK {
+		w.Write([]byte(testRemoteTable))
+	}
+}
+
+func TestWithRemote(t *testing.T) {
+	ts := &testRemote{}
+	srv := httptest.NewServer(ts)
+	defer srv.Close()
+	url := srv.URL + "/oui/oui.csv"
+	fallback := fstest.MapFS{"oui.csv": &fstest.MapFile{Data: []byte(testRemoteTable)}}
+
+	tests := []struct {
+		name       string
+		cache      bool
+		ttl        time.Duration
+		down       bool
+		portal     bool
+		fallback   bool
+		wantOrigin Origin
+		wantErr    bool
+		wantStatus []int
+	}{
+		{name: "download", wantOrigin: OriginNetwork, wantStatus: []int{200}},
+		{name: "fill cache", cache: true, wantOrigin: OriginNetwork, wantStatus: []int{200}},
+		{name: "fresh cache", cache: true, ttl: time.Hour, wantOrigin: OriginCache},
+		{name: "revalidate", cache: true, wantOrigin: OriginCache, wantStatus: []int{304}},
+		{name: "stale cache", cache: true, down: true, wantOrigin: OriginCache, wantStatus: []int{502}},
+		{name: "captive portal cache", cache: true, portal: true, wantOrigin: OriginCache, wantStatus: []int{200}},
+		{name: "captive portal fallback", portal: true, fallback: true, wantOrigin: OriginFallback, wantStatus: []int{200}},
+		{name: "captive portal", portal: true, wantErr: true, wantStatus: []int{200}},
+		{name: "fallback", down: true, fallback: true, wantOrigin: OriginFallback, wantStatus: []int{502}},
+		{name: "unavailable", down: true, wantErr: true, wantStatus: []int{502}},
+	}
+	dir := t.TempDir()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts.down, ts.portal, ts.status = tt.down, tt.portal, nil
+			var opts []RemoteOption
+			if tt.cache {
+				opts = append(opts, RemoteCache(dir, tt.ttl))
+			}
+			if tt.fallback {
+				opts = append(opts, RemoteFallback(fallback, "oui.csv"))
+			}
+			mp, err := New(WithRemote(context.Background(), url, opts...))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !cmp.Equal(ts.status, tt.wantStatus) {
+				t.Errorf("responses %v, want %v", ts.status, tt.wantStatus)
+			}
+			if err != nil {
+				return
+			}
+			sources := mp.Sources()
+			if len(sources) != 1 || sources[0].Name != url || sources[0].Origin != tt.wantOrigin {
+				t.Fatalf("sources %+v, want %s from %s", sources, url, tt.wantOrigin)
+			}
+			if (sources[0].Err != nil) != (tt.down || tt.portal) {
+				t.Errorf("source error = %v, want error %v", sources[0].Err, tt.down || tt.portal)
+			}
+			if a := mp.Get("00:d0:ef:01:02:03"); a == nil || a.Name != "IGT" || a.Source != url {
+				t.Errorf("Get() = %+v", a)
+			}
+		})
+	}
+}
+
+func TestWithRemote_Context(t *testing.T) {
+	srv := httptest.NewServer(&testRemote{})
+	defer srv.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := New(WithRemote(ctx, srv.URL+"/oui/oui.csv"))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("New() error = %v, want %v", err, context.Canceled)
+	}
+}
@@ -0,0 +1,130 @@
+package macpack
+
+import "math/bits"
+
+// trie is a path compressed binary prefix tree. Keys are left aligned, so
+// bit 0 of a prefix is the most significant bit of the uint64. Nodes are
+// stored in a slice and reference each other by index, the root node is
+// always located at index 0 and therefore 0 marks a missing child.
+type trie struct {
+	nodes []node
+}
+
+type node struct {
+	key   uint64
+	len   uint8
+	child [2]uint32
+	// val holds the index of the value + 1, 0 marks an inner node.
+	val uint32
+}
+
+func newTrie() trie {
+	return trie{nodes: make([]node, 1)}
+}
+
+// insert stores val for the first n bits of key. An existing value for the
+// same prefix is replaced.
+func (t *trie) insert(key uint64, n uint8, val uint32) {
+	key = mask(key, n)
+	cur := uint32(0)
+	for {
+		c := &t.nodes[cur]
+		if c.len == n {
+			c.val = val + 1
+			return
+		}
+		b := bit(key, c.len)
+		next := c.child[b]
+		if next == 0 {
+			leaf := t.add(node{key: key, len: n, val: val + 1})
+			t.nodes[cur].child[b] = leaf
+			return
+		}
+		nn := t.nodes[next]
+		l := commonLen(key, nn.key, n, nn.len)
+		if l == nn.len {
+			cur = next
+			continue
+		}
+		// split the edge between cur and next at the common prefix.
+		mid := node{key: mask(key, l), len: l}
+		mid.child[bit(nn.key, l)] = next
+		if l == n {
+			mid.val = val + 1
+		} else {
+			mid.child[bit(key, l)] = t.add(node{key: key, len: n, val: val + 1})
+		}
+		idx := t.add(mid)
+		t.nodes[cur].child[b] = idx
+		return
+	}
+}
+
+// lookup returns the value of the longest prefix that matches the first n
+// bits of key.
+func (t *trie) lookup(key uint64, n uint8) (val uint32, ok bool) {
+	var best uint32
+	cur := &t.nodes[0]
+	if cur.val != 0 {
+		best = cur.val
+	}
+	for cur.len < n {
+		next := cur.child[bit(key, cur.len)]
+		if next == 0 {
+			break
+		}
+		nn := &t.nodes[next]
+		if nn.len > n || mask(key, nn.len) != nn.key {
+			break
+		}
+		if nn.val != 0 {
+			best = nn.val
+		}
+		cur = nn
+	}
+	return best - 1, best != 0
+}
+
+// get returns the value stored for exactly the first n bits of key.
+func (t *trie) get(key uint64, n uint8) (val uint32, ok bool) {
+	key = mask(key, n)
+	cur := &t.nodes[0]
+	for cur.len < n {
+		next := cur.child[bit(key, cur.len)]
+		if next == 0 {
+			return 0, false
+		}
+		cur = &t.nodes[next]
+		if cur.len > n || mask(key, cur.len) != cur.key {
+			return 0, false
+		}
+	}
+	return cur.val - 1, cur.len == n && cur.val != 0
+}
+
+func (t *trie) add(n node) uint32 {
+	t.nodes = append(t.nodes, n)
+	return uint32(len(t.nodes) - 1)
+}
+
+func bit(key uint64, i uint8) int {
+	return int(key>>(63-i)) & 1
+}
+
+func mask(key uint64, n uint8) uint64 {
+	if n == 0 {
+		return 0
+	}
+	return key &^ (^uint64(0) >> n)
+}
+
+func commonLen(a, b uint64, na, nb uint8) uint8 {
+	l := uint8(bits.LeadingZeros64(a ^ b))
+	if l > na {
+		l = na
+	}
+	if l > nb {
+		l = nb
+	}
+	return l
+}
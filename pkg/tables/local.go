@@ -2,7 +2,24 @@ package	tables
 
 import "embed"
 
-//go:embed *.csv
+//go:generate go run ../../cmd/tablegen -o oui.idx.gz oui.csv
+//go:generate go run ../../cmd/tablegen -o mam.idx.gz mam.csv
+//go:generate go run ../../cmd/tablegen -o oui36.idx.gz oui36.csv
+
+// Names of the embedded lookup tables. They are precompiled, gzip compressed
+// binary indexes generated from the csv files of the IEEE registries.
+const (
+	MACLarge  = "oui.idx.gz"
+	MACMedium = "mam.idx.gz"
+	MACSmall  = "oui36.idx.gz"
+)
+
+// WellKnown is the name of the embedded table of well-known ranges, like
+// virtual network interfaces, multicast groups and virtual routers.
+// Layout: Prefix,Type,Name
+const WellKnown = "wellknown.csv"
+
+//go:embed *.idx.gz wellknown.csv
 var f embed.FS
 
 // Get returns the embedded lookup tables